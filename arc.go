@@ -0,0 +1,167 @@
+package lru
+
+/*
+	Adaptive Replacement Cache (Megiddo and Modha, 2003).
+
+	The resident keys are split between two lists: T1 keeps the keys which were seen once recently and T2 keeps
+	the keys which were seen at least twice. The ghost lists B1 and B2 remember the keys recently evicted from T1 and T2.
+	A new key which is found in B1 means T1 is too small, so the target size of T1 grows, a key found in B2 shrinks it.
+	The victim is taken from T1 when it's bigger than its target and from T2 otherwise.
+
+	All lists keep the most recently used key at the head
+*/
+
+type arcPolicy[K comparable] struct {
+	capacity int
+	// target is the target size of T1, it's called p in the paper
+	target  int
+	entries map[K]*queueEntry[K]
+	t1, t2  popularityList[K]
+	b1, b2  popularityList[K]
+	// ghostHitB2 is set when the new key was found in B2, it breaks the tie between T1 and T2 in Victim
+	ghostHitB2 bool
+	// victim is the last key returned by Victim, it goes to a ghost list when it's removed
+	victim    K
+	hasVictim bool
+}
+
+// NewARCPolicy creates a policy which adapts to the workload with the ARC algorithm. It needs the capacity of the cache
+// to size the ghost lists, so it's usually created with WithOrdering(AdaptiveReplacement) or NewARCCache
+func NewARCPolicy[K comparable](capacity int) Policy[K] {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &arcPolicy[K]{capacity: capacity, entries: make(map[K]*queueEntry[K])}
+}
+
+// NewARCCache creates an ARC cache with a map as a backend. WithOrdering and WithAging don't apply to it
+func NewARCCache[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return NewMapLRU(capacity, append(opts, WithOrdering[K, V](AdaptiveReplacement))...)
+}
+
+// onMiss adapts the target size of T1 when the new key is a ghost
+func (p *arcPolicy[K]) onMiss(key K) {
+	p.ghostHitB2 = false
+
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	switch entry.list {
+	case &p.b1:
+		p.target = min(p.target+max(p.b2.size/p.b1.size, 1), p.capacity)
+	case &p.b2:
+		p.target = max(p.target-max(p.b1.size/p.b2.size, 1), 0)
+		p.ghostHitB2 = true
+	}
+}
+
+func (p *arcPolicy[K]) OnInsert(key K) {
+	entry, ok := p.entries[key]
+	switch {
+	case !ok:
+		entry = &queueEntry[K]{node: popularityNode[K]{key: key}}
+		p.entries[key] = entry
+		p.push(entry, &p.t1)
+
+	case entry.list == &p.b1 || entry.list == &p.b2:
+		// the key was seen before it was evicted, so it's frequent now
+		entry.list.unlink(&entry.node)
+		p.push(entry, &p.t2)
+
+	default:
+		p.OnAccess(key)
+		return
+	}
+
+	entry.node.hits = 1
+	p.trimGhosts()
+}
+
+func (p *arcPolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.list == &p.b1 || entry.list == &p.b2 {
+		return
+	}
+
+	entry.node.hits++
+	entry.list.unlink(&entry.node)
+	p.push(entry, &p.t2)
+}
+
+// OnRemove moves the victim to the ghost list of its list, the keys removed for other reasons are forgotten
+func (p *arcPolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.list == &p.b1 || entry.list == &p.b2 {
+		return
+	}
+
+	entry.list.unlink(&entry.node)
+	if !p.hasVictim || p.victim != key {
+		delete(p.entries, key)
+		return
+	}
+
+	p.hasVictim = false
+	if entry.list == &p.t1 {
+		p.push(entry, &p.b1)
+	} else {
+		p.push(entry, &p.b2)
+	}
+	p.trimGhosts()
+}
+
+func (p *arcPolicy[K]) Victim() (key K, found bool) {
+	list := &p.t2
+	if p.t1.size > 0 && (p.t1.size > p.target || (p.ghostHitB2 && p.t1.size == p.target) || p.t2.size == 0) {
+		list = &p.t1
+	}
+
+	if list.popularityTail == nil {
+		return key, false
+	}
+
+	p.victim, p.hasVictim = list.popularityTail.key, true
+	return p.victim, true
+}
+
+func (p *arcPolicy[K]) push(entry *queueEntry[K], list *popularityList[K]) {
+	entry.list = list
+	list.pushFront(&entry.node)
+}
+
+// trimGhosts keeps T1 and B1 within the capacity and all lists within twice the capacity
+func (p *arcPolicy[K]) trimGhosts() {
+	for p.b1.size > 0 && p.t1.size+p.b1.size > p.capacity {
+		p.forget(&p.b1)
+	}
+
+	for p.b2.size > 0 && p.t1.size+p.t2.size+p.b1.size+p.b2.size > 2*p.capacity {
+		p.forget(&p.b2)
+	}
+}
+
+// forget drops the least recently used key of the ghost list
+func (p *arcPolicy[K]) forget(list *popularityList[K]) {
+	node := list.popularityTail
+	list.unlink(node)
+	delete(p.entries, node.key)
+}
+
+// topK returns the keys of T2 followed by the keys of T1
+func (p *arcPolicy[K]) topK(n int) []KeyHits[K] {
+	keys := p.t2.topK(n)
+	return append(keys, p.t1.topK(n-len(keys))...)
+}
+
+// coldest returns the keys of T1 followed by the keys of T2, starting from their tails
+func (p *arcPolicy[K]) coldest(n int) []KeyHits[K] {
+	keys := p.t1.coldest(n)
+	return append(keys, p.t2.coldest(n-len(keys))...)
+}
+
+func (p *arcPolicy[K]) extractPopularityKeys() []K {
+	return append(p.t2.extractPopularityKeys(), p.t1.extractPopularityKeys()...)
+}
@@ -0,0 +1,126 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARCCache_Clear(t *testing.T) {
+	testLRUCacheClear(t, NewARCCache[string, string])
+}
+
+func TestARCCache_Replace(t *testing.T) {
+	testLRUCacheReplace(t, NewARCCache[string, string])
+}
+
+func TestARCCache_ExpireAfterAccess(t *testing.T) {
+	testLRUCacheExpireAfterAccess(t, NewARCCache[string, string])
+}
+
+func TestARCCache_SetAndEvict(t *testing.T) {
+	testLRUCacheSetAndEvict(t, NewARCCache[string, string])
+}
+
+func TestARCCache_Stats(t *testing.T) {
+	testLRUCacheStats(t, NewARCCache[string, string])
+}
+
+func TestARCCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewARCCache[int, int])
+}
+
+func TestARCCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewARCCache[int, int])
+}
+
+func TestARCCache_Adaptation(t *testing.T) {
+	cache := NewARCCache[string, string](4).(*cache[string, string])
+	policy := cache.policy.(*arcPolicy[string])
+
+	for _, key := range []string{"x", "y", "x", "y", "a", "b"} {
+		cache.Set(key, "value "+key)
+	}
+	assert.Equal(t, []string{"y", "x", "b", "a"}, cache.extractPopularityKeys())
+
+	// T1 is bigger than its target, so its oldest key becomes a ghost in B1
+	cache.Set("c", "value c")
+	assert.False(t, cache.Contains("a"))
+	assert.Equal(t, []string{"a"}, policy.b1.extractPopularityKeys())
+	assert.Equal(t, 0, policy.target)
+
+	// a ghost hit in B1 grows the target of T1 and brings the key back as a frequent one
+	cache.Set("a", "value a")
+	assert.Equal(t, 1, policy.target)
+	assert.Equal(t, []string{"a", "y", "x", "c"}, cache.extractPopularityKeys())
+	assert.Equal(t, []string{"b"}, policy.b1.extractPopularityKeys())
+
+	// T1 is at its target, so the victim comes from T2
+	cache.Set("d", "value d")
+	assert.False(t, cache.Contains("x"))
+	assert.Equal(t, []string{"x"}, policy.b2.extractPopularityKeys())
+
+	// a ghost hit in B2 shrinks the target of T1
+	cache.Set("x", "value x")
+	assert.Equal(t, 0, policy.target)
+	assert.Equal(t, []string{"x", "a", "y", "d"}, cache.extractPopularityKeys())
+	assert.Equal(t, []string{"c", "b"}, policy.b1.extractPopularityKeys())
+}
+
+func TestARCCache_GhostListsAreBounded(t *testing.T) {
+	cache := NewARCCache[int, int](8).(*cache[int, int])
+	policy := cache.policy.(*arcPolicy[int])
+
+	for i := 0; i < 1000; i++ {
+		key := i % 37
+		cache.Set(key, key)
+		if i%3 == 0 {
+			cache.Get(key)
+		}
+
+		assert.LessOrEqual(t, policy.t1.size+policy.b1.size, 8)
+		assert.LessOrEqual(t, policy.t1.size+policy.t2.size+policy.b1.size+policy.b2.size, 16)
+		assert.LessOrEqual(t, len(policy.entries), 16)
+		assert.Equal(t, cache.Size(), policy.t1.size+policy.t2.size)
+	}
+
+	cache.Delete(cache.extractPopularityKeys()[0])
+	assert.Equal(t, cache.Size(), policy.t1.size+policy.t2.size, "deleted keys aren't ghosts")
+}
+
+// TestARCCache_ScanResistance checks that a scan of keys used once doesn't flush the keys used often
+func TestARCCache_ScanResistance(t *testing.T) {
+	scan := func(cache LRU[string, string]) {
+		for _, key := range []string{"hot 1", "hot 2", "hot 1", "hot 2"} {
+			cache.Set(key, "value")
+		}
+
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			cache.Set(key, "value "+key)
+		}
+	}
+
+	arc := NewARCCache[string, string](4)
+	scan(arc)
+	assert.True(t, arc.Contains("hot 1"))
+	assert.True(t, arc.Contains("hot 2"))
+
+	lru := NewMapLRU[string, string](4, WithOrdering[string, string](LeastRecentlyUsed))
+	scan(lru)
+	assert.False(t, lru.Contains("hot 1"))
+	assert.False(t, lru.Contains("hot 2"))
+}
+
+func TestARCCache_TopK(t *testing.T) {
+	cache := NewARCCache[string, string](3)
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+	cache.Set("c", "value c")
+	cache.Get("b")
+	cache.Get("b")
+
+	assert.Equal(t, []KeyHits[string]{{"b", 3}, {"c", 1}}, cache.TopK(2))
+	assert.Equal(t, []KeyHits[string]{{"a", 1}, {"c", 1}, {"b", 3}}, cache.Coldest(5))
+}
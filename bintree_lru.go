@@ -1,95 +1,63 @@
 package lru
 
-type bintreeLRUItem struct {
-	key             string
-	value           interface{}
-	hits            int
-	parent          *bintreeLRUItem
-	left            *bintreeLRUItem
-	right           *bintreeLRUItem
-	morePopularNode *bintreeLRUItem
-	lessPopularNode *bintreeLRUItem
+import "cmp"
+
+type bintreeStorageItem[K cmp.Ordered, V any] struct {
+	key    K
+	entry  *cacheEntry[K, V]
+	parent *bintreeStorageItem[K, V]
+	left   *bintreeStorageItem[K, V]
+	right  *bintreeStorageItem[K, V]
+	// height is the number of nodes on the longest path from the node down to a leaf
+	height int
 }
 
-type bintreeLRU struct {
-	tip            *bintreeLRUItem
-	capacity       int
-	size           int
-	popularityTail *bintreeLRUItem
+// bintreeStorage keeps the entries in a self-balancing binary search tree
+type bintreeStorage[K cmp.Ordered, V any] struct {
+	tip  *bintreeStorageItem[K, V]
+	size int
 }
 
-// NewBintreeLRU creates an instance of the LRU cache with the binary tree as a backend
-func NewBintreeLRU(capacity int) LRU {
-	if capacity <= 0 {
-		capacity = 1
-	}
-
-	return &bintreeLRU{capacity: capacity, size: 0}
+// NewBintreeLRU creates an instance of the LRU cache with the binary tree as a backend. Keys have to be ordered
+func NewBintreeLRU[K cmp.Ordered, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return newCache(capacity, newBintreeStorage[K, V], opts)
 }
 
-func (l *bintreeLRU) Get(key string) (found bool, value interface{}) {
-	tip := l.tip
-	for {
-		if tip == nil {
-			return false, nil
-		}
-
-		if tip.key == key {
-			tip.hits++
-			l.swap(tip)
-
-			return true, tip.value
-		}
+func newBintreeStorage[K cmp.Ordered, V any](int) storage[K, V] {
+	return &bintreeStorage[K, V]{}
+}
 
-		if tip.key > key {
-			tip = tip.left
-		} else {
-			tip = tip.right
-		}
+func (l *bintreeStorage[K, V]) get(key K) *cacheEntry[K, V] {
+	if node := l.find(key); node != nil {
+		return node.entry
 	}
+
+	return nil
 }
 
-func (l *bintreeLRU) Set(key string, value interface{}) {
+func (l *bintreeStorage[K, V]) add(entry *cacheEntry[K, V]) {
 	if l.tip == nil {
-		l.tip = &bintreeLRUItem{
-			key:             key,
-			value:           value,
-			hits:            1,
-			parent:          nil,
-			left:            nil,
-			right:           nil,
-			morePopularNode: nil,
-			lessPopularNode: nil,
-		}
-
+		l.tip = &bintreeStorageItem[K, V]{key: entry.key, entry: entry, height: 1}
 		l.size = 1
-		l.popularityTail = l.tip
 		return
 	}
 
 	node := l.tip
 	for {
-		switch {
-		case node.key == key:
-			node.hits++
-			l.swap(node)
+		// cmp.Compare orders NaN before the other floats, the operators would never match a NaN key
+		switch cmp.Compare(node.key, entry.key) {
+		case 0:
+			node.entry = entry
 			return
 
-		case node.key > key:
+		case 1:
 			if node.left != nil {
 				node = node.left
 				continue
 			}
 
-			if l.size == l.capacity {
-				l.evict()
-				l.Set(key, value)
-				return
-			}
-
 			// add
-			node.left = l.newNode(key, value)
-			node.left.parent = node
+			node.left = &bintreeStorageItem[K, V]{key: entry.key, entry: entry, parent: node, height: 1}
 
 			// rebalance
 			l.rebalance(node)
@@ -97,21 +65,14 @@ func (l *bintreeLRU) Set(key string, value interface{}) {
 			l.size++
 			return
 
-		case node.key < key:
+		default:
 			if node.right != nil {
 				node = node.right
 				continue
 			}
 
-			if l.size == l.capacity {
-				l.evict()
-				l.Set(key, value)
-				return
-			}
-
 			// add
-			node.right = l.newNode(key, value)
-			node.right.parent = node
+			node.right = &bintreeStorageItem[K, V]{key: entry.key, entry: entry, parent: node, height: 1}
 
 			// rebalance
 			l.rebalance(node)
@@ -122,50 +83,58 @@ func (l *bintreeLRU) Set(key string, value interface{}) {
 	}
 }
 
-func (l *bintreeLRU) Size() int {
-	return l.size
-}
-
-func (l *bintreeLRU) extractPopularityKeys() []string {
-	popularityList := make([]string, 0, l.size)
-	tail := l.popularityTail
-	for tail != nil {
-		popularityList = append([]string{tail.key}, popularityList...)
-		tail = tail.morePopularNode
+func (l *bintreeStorage[K, V]) remove(key K) *cacheEntry[K, V] {
+	node := l.find(key)
+	if node == nil {
+		return nil
 	}
 
-	return popularityList
+	l.removeNode(node)
+	return node.entry
 }
 
-func (l *bintreeLRU) swap(node *bintreeLRUItem) {
-	for {
-		if node == nil || node.morePopularNode == nil || node.hits <= node.morePopularNode.hits {
-			return
-		}
+func (l *bintreeStorage[K, V]) len() int {
+	return l.size
+}
+
+func (l *bintreeStorage[K, V]) backend() string {
+	return "bintree"
+}
 
-		currentNextNode := node.morePopularNode
-		nextNextNode := currentNextNode.morePopularNode
+func (l *bintreeStorage[K, V]) clear() {
+	l.tip = nil
+	l.size = 0
+}
 
-		currentNextNode.morePopularNode = node
-		currentNextNode.lessPopularNode = node.lessPopularNode
-		node.morePopularNode = nextNextNode
-		node.lessPopularNode = currentNextNode
+func (l *bintreeStorage[K, V]) each(fn func(entry *cacheEntry[K, V]) bool) {
+	l.walk(l.tip, fn)
+}
 
-		if nextNextNode != nil {
-			nextNextNode.lessPopularNode = node
-		}
+func (l *bintreeStorage[K, V]) walk(node *bintreeStorageItem[K, V], fn func(entry *cacheEntry[K, V]) bool) bool {
+	if node == nil {
+		return true
+	}
 
-		if currentNextNode.lessPopularNode != nil {
-			currentNextNode.lessPopularNode.morePopularNode = currentNextNode
-		}
+	return l.walk(node.left, fn) && fn(node.entry) && l.walk(node.right, fn)
+}
 
-		if l.popularityTail == node {
-			l.popularityTail = currentNextNode
+func (l *bintreeStorage[K, V]) find(key K) *bintreeStorageItem[K, V] {
+	tip := l.tip
+	for tip != nil {
+		switch cmp.Compare(tip.key, key) {
+		case 0:
+			return tip
+		case 1:
+			tip = tip.left
+		default:
+			tip = tip.right
 		}
 	}
+
+	return nil
 }
 
-func (l *bintreeLRU) findBiggestInSubtree(node *bintreeLRUItem) *bintreeLRUItem {
+func (l *bintreeStorage[K, V]) findBiggestInSubtree(node *bintreeStorageItem[K, V]) *bintreeStorageItem[K, V] {
 	tip := node
 	for {
 		if tip.right == nil {
@@ -176,22 +145,19 @@ func (l *bintreeLRU) findBiggestInSubtree(node *bintreeLRUItem) *bintreeLRUItem
 	}
 }
 
-func (l *bintreeLRU) evict() {
-	if l.size < l.capacity || l.popularityTail == nil {
-		return
-	}
-
+// removeNode unlinks the node from the tree and rebalances the tree afterwards
+func (l *bintreeStorage[K, V]) removeNode(nodeToDelete *bintreeStorageItem[K, V]) {
 	if l.size == 1 {
 		l.tip = nil
-		l.popularityTail = nil
 		l.size = 0
 		return
 	}
 
-	nodeToDelete := l.popularityTail
-	var newParent *bintreeLRUItem
+	var newParent *bintreeStorageItem[K, V]
+	// the deepest node whose subtree has changed, the rebalancing starts from it
+	var rebalanceFrom *bintreeStorageItem[K, V]
 
-	if l.tip.key == nodeToDelete.key {
+	if l.tip == nodeToDelete {
 		// remove the tip
 		if l.tip.left != nil {
 			newParent = l.findBiggestInSubtree(l.tip.left)
@@ -205,6 +171,7 @@ func (l *bintreeLRU) evict() {
 
 				newParent.left = l.tip.left
 				newParent.left.parent = newParent
+				rebalanceFrom = newParentsParent
 			}
 
 			newParent.right = l.tip.right
@@ -215,11 +182,6 @@ func (l *bintreeLRU) evict() {
 			newParent = l.tip.right
 		}
 
-		l.popularityTail = l.popularityTail.morePopularNode
-		if l.popularityTail != nil {
-			l.popularityTail.lessPopularNode = nil
-		}
-
 		l.tip = newParent
 		l.tip.parent = nil
 	} else if nodeToDelete.left == nil && nodeToDelete.right == nil {
@@ -231,11 +193,6 @@ func (l *bintreeLRU) evict() {
 			nodeToDelete.parent.right = nil
 		}
 
-		l.popularityTail = l.popularityTail.morePopularNode
-		if l.popularityTail != nil {
-			l.popularityTail.lessPopularNode = nil
-		}
-
 		newParent = nodeToDelete.parent
 
 	} else {
@@ -257,6 +214,7 @@ func (l *bintreeLRU) evict() {
 
 				newParent.left = nodeToDelete.left
 				newParent.left.parent = newParent
+				rebalanceFrom = newParentsParent
 			}
 
 			newParent.right = nodeToDelete.right
@@ -267,11 +225,6 @@ func (l *bintreeLRU) evict() {
 			newParent = nodeToDelete.right
 		}
 
-		l.popularityTail = l.popularityTail.morePopularNode
-		if l.popularityTail != nil {
-			l.popularityTail.lessPopularNode = nil
-		}
-
 		if parentNode.left == nodeToDelete {
 			parentNode.left = newParent
 			parentNode.left.parent = parentNode
@@ -281,104 +234,107 @@ func (l *bintreeLRU) evict() {
 		}
 	}
 
-	l.size--
-	l.rebalance(newParent)
-}
-
-func (l *bintreeLRU) newNode(key string, value interface{}) *bintreeLRUItem {
-	newNode := &bintreeLRUItem{
-		key:             key,
-		value:           value,
-		hits:            1,
-		left:            nil,
-		right:           nil,
-		morePopularNode: l.popularityTail,
-		lessPopularNode: nil,
-	}
+	nodeToDelete.parent = nil
+	nodeToDelete.left = nil
+	nodeToDelete.right = nil
 
-	l.popularityTail = newNode
-	if newNode.morePopularNode != nil {
-		newNode.morePopularNode.lessPopularNode = newNode
+	l.size--
+	if rebalanceFrom == nil {
+		rebalanceFrom = newParent
 	}
-
-	return newNode
+	l.rebalance(rebalanceFrom)
 }
 
-func (l *bintreeLRU) maxDepth(node *bintreeLRUItem) int {
-	if node == nil {
+func (n *bintreeStorageItem[K, V]) getHeight() int {
+	if n == nil {
 		return 0
 	}
 
-	leftDepth := l.maxDepth(node.left)
-	rightDepth := l.maxDepth(node.right)
-
-	if leftDepth > rightDepth {
-		return leftDepth + 1
-	}
-
-	return rightDepth + 1
+	return n.height
 }
 
-func (l *bintreeLRU) rebalance(node *bintreeLRUItem) {
-	if node == nil {
-		return
-	}
-
-	leftDepth := l.maxDepth(node.left)
-	rightDepth := l.maxDepth(node.right)
+// balance returns the difference between the heights of the left and the right subtrees
+func (n *bintreeStorageItem[K, V]) balance() int {
+	return n.left.getHeight() - n.right.getHeight()
+}
 
-	nodeParent := node.parent
+func (n *bintreeStorageItem[K, V]) updateHeight() {
+	n.height = max(n.left.getHeight(), n.right.getHeight()) + 1
+}
 
-	if leftDepth > rightDepth+1 {
-		newParent := node.left
-		newParent.parent = nodeParent
+// rebalance walks from the node up to the tip, updates the heights of the nodes and rotates the subtrees
+// which became unbalanced the AVL way, so the heights of the subtrees of every node differ by 1 at most
+func (l *bintreeStorage[K, V]) rebalance(node *bintreeStorageItem[K, V]) {
+	for node != nil {
+		node.updateHeight()
+
+		switch balance := node.balance(); {
+		case balance > 1:
+			if node.left.balance() < 0 {
+				// left-right case
+				l.rotateLeft(node.left)
+			}
+			node = l.rotateRight(node)
 
-		node.left = newParent.right
-		if node.left != nil {
-			node.left.parent = node
+		case balance < -1:
+			if node.right.balance() > 0 {
+				// right-left case
+				l.rotateRight(node.right)
+			}
+			node = l.rotateLeft(node)
 		}
 
-		newParent.right = node
-		newParent.right.parent = newParent
+		node = node.parent
+	}
+}
 
-		if nodeParent == nil {
-			l.tip = newParent
-			l.tip.parent = nil
+// rotateRight replaces the node with its left child and returns the new root of the subtree
+func (l *bintreeStorage[K, V]) rotateRight(node *bintreeStorageItem[K, V]) *bintreeStorageItem[K, V] {
+	newParent := node.left
 
-		} else if nodeParent.left == node {
-			nodeParent.left = newParent
-			nodeParent.left.parent = nodeParent
+	node.left = newParent.right
+	if node.left != nil {
+		node.left.parent = node
+	}
 
-		} else if nodeParent.right == node {
-			nodeParent.right = newParent
-			nodeParent.right.parent = nodeParent
-		}
+	newParent.right = node
+	l.replaceChild(node, newParent)
+	node.parent = newParent
 
-	} else if rightDepth > leftDepth+1 {
-		newParent := node.right
-		newParent.parent = nodeParent
+	node.updateHeight()
+	newParent.updateHeight()
+	return newParent
+}
 
-		node.right = newParent.left
-		if node.right != nil {
-			node.right.parent = node
-		}
+// rotateLeft replaces the node with its right child and returns the new root of the subtree
+func (l *bintreeStorage[K, V]) rotateLeft(node *bintreeStorageItem[K, V]) *bintreeStorageItem[K, V] {
+	newParent := node.right
 
-		newParent.left = node
-		newParent.left.parent = newParent
+	node.right = newParent.left
+	if node.right != nil {
+		node.right.parent = node
+	}
 
-		if nodeParent == nil {
-			l.tip = newParent
-			l.tip.parent = nil
+	newParent.left = node
+	l.replaceChild(node, newParent)
+	node.parent = newParent
 
-		} else if nodeParent.left == node {
-			nodeParent.left = newParent
-			nodeParent.left.parent = nodeParent
+	node.updateHeight()
+	newParent.updateHeight()
+	return newParent
+}
 
-		} else if nodeParent.right == node {
-			nodeParent.right = newParent
-			nodeParent.right.parent = nodeParent
-		}
+// replaceChild puts newChild in place of child in the parent of child
+func (l *bintreeStorage[K, V]) replaceChild(child, newChild *bintreeStorageItem[K, V]) {
+	parent := child.parent
+	newChild.parent = parent
+
+	switch {
+	case parent == nil:
+		l.tip = newChild
+	case parent.left == child:
+		parent.left = newChild
+	default:
+		parent.right = newChild
 	}
-
-	l.rebalance(nodeParent)
 }
@@ -1,6 +1,11 @@
 package lru
 
 import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -8,7 +13,36 @@ import (
 )
 
 func TestBintreeLRUCache(t *testing.T) {
-	testLRUCache(t, NewBintreeLRU)
+	testLRUCache(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_IntKeys(t *testing.T) {
+	testLRUCacheIntKeys(t, NewBintreeLRU[int, []byte])
+}
+
+func TestBintreeLRUCache_Delete(t *testing.T) {
+	testLRUCacheDelete(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Peek(t *testing.T) {
+	testLRUCachePeek(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Clear(t *testing.T) {
+	testLRUCacheClear(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Replace(t *testing.T) {
+	testLRUCacheReplace(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_LeastRecentlyUsed(t *testing.T) {
+	testLRUCacheLeastRecentlyUsed(t, NewBintreeLRU[string, string])
+}
+
+func newTestBintreeLRU[K cmp.Ordered, V any](capacity int) (*cache[K, V], *bintreeStorage[K, V]) {
+	c := NewBintreeLRU[K, V](capacity).(*cache[K, V])
+	return c, c.storage.(*bintreeStorage[K, V])
 }
 
 type simplifiedBinTreeItem struct {
@@ -16,7 +50,7 @@ type simplifiedBinTreeItem struct {
 	right string
 }
 
-func extractBinTreeItems(tip *bintreeLRUItem) map[string]simplifiedBinTreeItem {
+func extractBinTreeItems(tip *bintreeStorageItem[string, string]) map[string]simplifiedBinTreeItem {
 	m := make(map[string]simplifiedBinTreeItem)
 	if tip == nil {
 		return m
@@ -152,15 +186,15 @@ func TestBintreeLRUCache_Set(t *testing.T) {
 				"h", "f", "a", "l", "s", "j", "k", "c", "m", "d"},
 			wantItemsTree: map[string]simplifiedBinTreeItem{
 				"a": {left: "", right: ""},
-				"c": {left: "a", right: "d"},
+				"c": {left: "a", right: "f"},
 				"d": {left: "", right: ""},
-				"f": {left: "c", right: "j"},
+				"f": {left: "d", right: "h"},
 				"h": {left: "", right: ""},
-				"j": {left: "h", right: ""},
-				"k": {left: "f", right: "m"},
-				"l": {left: "", right: ""},
-				"m": {left: "l", right: "s"},
-				"s": {left: "", right: ""},
+				"j": {left: "c", right: "l"},
+				"k": {left: "", right: ""},
+				"l": {left: "k", right: "s"},
+				"m": {left: "", right: ""},
+				"s": {left: "m", right: ""},
 			},
 		},
 	}
@@ -168,7 +202,7 @@ func TestBintreeLRUCache_Set(t *testing.T) {
 	for _, test := range tests {
 		tt := test
 		t.Run(tt.name, func(t *testing.T) {
-			cache := NewBintreeLRU(tt.capacity).(*bintreeLRU)
+			cache, tree := newTestBintreeLRU[string, string](tt.capacity)
 			for _, key := range tt.items {
 				cache.Set(key, "")
 			}
@@ -178,7 +212,7 @@ func TestBintreeLRUCache_Set(t *testing.T) {
 
 			assert.Equal(t, tt.wantItemsPriority, gotPopularityList)
 
-			gotTreeStruct := extractBinTreeItems(cache.tip)
+			gotTreeStruct := extractBinTreeItems(tree.tip)
 			assert.Equal(t, tt.wantItemsTree, gotTreeStruct)
 		})
 	}
@@ -209,12 +243,12 @@ func TestBintreeLRUCache_findBiggestInSubTree(t *testing.T) {
 	for _, test := range tests {
 		tt := test
 		t.Run(tt.name, func(t *testing.T) {
-			cache := NewBintreeLRU(tt.capacity).(*bintreeLRU)
+			cache, tree := newTestBintreeLRU[string, string](tt.capacity)
 			for _, key := range tt.items {
 				cache.Set(key, "")
 			}
 
-			gotBiggest := cache.findBiggestInSubtree(cache.tip.left)
+			gotBiggest := tree.findBiggestInSubtree(tree.tip.left)
 			assert.Equal(t, tt.wantElement, gotBiggest.key)
 		})
 	}
@@ -328,7 +362,7 @@ func TestBintreeLRUCache_evict(t *testing.T) {
 	for _, test := range tests {
 		tt := test
 		t.Run(tt.name, func(t *testing.T) {
-			cache := NewBintreeLRU(tt.capacity).(*bintreeLRU)
+			cache, tree := newTestBintreeLRU[string, string](tt.capacity)
 			for _, key := range tt.items {
 				cache.Set(key, "")
 			}
@@ -340,8 +374,242 @@ func TestBintreeLRUCache_evict(t *testing.T) {
 
 			assert.Equal(t, tt.wantItemsPriority, gotPopularityList)
 
-			gotTreeStruct := extractBinTreeItems(cache.tip)
+			gotTreeStruct := extractBinTreeItems(tree.tip)
 			assert.Equal(t, tt.wantItemsTree, gotTreeStruct)
 		})
 	}
 }
+
+func TestBintreeLRUCache_remove(t *testing.T) {
+	tests := []struct {
+		name              string
+		items             []string
+		deleteItems       []string
+		capacity          int
+		wantSize          int
+		wantItemsPriority []string
+		wantItemsTree     map[string]simplifiedBinTreeItem
+	}{
+		{
+			name:              "delete tip",
+			items:             strings.Split("abcde", ""),
+			deleteItems:       []string{"b"},
+			capacity:          10,
+			wantSize:          4,
+			wantItemsPriority: strings.Split("acde", ""),
+			wantItemsTree: map[string]simplifiedBinTreeItem{
+				"a": {left: "", right: "c"},
+				"c": {left: "", right: ""},
+				"d": {left: "a", right: "e"},
+				"e": {left: "", right: ""},
+			},
+		},
+		{
+			name:              "delete leaf",
+			items:             strings.Split("abcde", ""),
+			deleteItems:       []string{"e"},
+			capacity:          10,
+			wantSize:          4,
+			wantItemsPriority: strings.Split("abcd", ""),
+			wantItemsTree: map[string]simplifiedBinTreeItem{
+				"a": {left: "", right: ""},
+				"b": {left: "a", right: "d"},
+				"c": {left: "", right: ""},
+				"d": {left: "c", right: ""},
+			},
+		},
+		{
+			name:              "delete inner node",
+			items:             strings.Split("abcdefghijklmno", ""),
+			deleteItems:       []string{"l"},
+			capacity:          20,
+			wantSize:          14,
+			wantItemsPriority: strings.Split("abcdefghijkmno", ""),
+			wantItemsTree: map[string]simplifiedBinTreeItem{
+				"a": {left: "", right: ""},
+				"b": {left: "a", right: "c"},
+				"c": {left: "", right: ""},
+				"d": {left: "b", right: "f"},
+				"e": {left: "", right: ""},
+				"f": {left: "e", right: "g"},
+				"g": {left: "", right: ""},
+				"h": {left: "d", right: "k"},
+				"i": {left: "", right: ""},
+				"j": {left: "i", right: ""},
+				"k": {left: "j", right: "n"},
+				"m": {left: "", right: ""},
+				"n": {left: "m", right: "o"},
+				"o": {left: "", right: ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			cache, tree := newTestBintreeLRU[string, string](tt.capacity)
+			for _, key := range tt.items {
+				cache.Set(key, "")
+			}
+
+			for _, key := range tt.deleteItems {
+				assert.True(t, cache.Delete(key))
+			}
+
+			assert.Equal(t, tt.wantSize, cache.Size())
+			assert.Equal(t, tt.wantItemsPriority, cache.extractPopularityKeys())
+			assert.Equal(t, tt.wantItemsTree, extractBinTreeItems(tree.tip))
+		})
+	}
+}
+
+func TestBintreeLRUCache_removeKeepsTreeValid(t *testing.T) {
+	cache, tree := newTestBintreeLRU[int, int](1000)
+	for i := 0; i < 1000; i++ {
+		cache.Set(i, i)
+	}
+
+	for i := 0; i < 1000; i += 3 {
+		assert.True(t, cache.Delete(i))
+	}
+
+	assert.Equal(t, cache.Size(), checkBintree(t, tree))
+	assert.Equal(t, cache.Size(), len(cache.extractPopularityKeys()))
+}
+
+func TestBintreeLRUCache_RandomKeysKeepTreeBalanced(t *testing.T) {
+	cache, tree := newTestBintreeLRU[int, int](20000)
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20000; i++ {
+		key := rnd.Intn(100000)
+		cache.Set(key, key)
+	}
+	assert.Equal(t, cache.Size(), checkBintree(t, tree))
+
+	for i := 0; i < 50000; i++ {
+		key := rnd.Intn(100000)
+		if rnd.Intn(2) == 0 {
+			cache.Delete(key)
+		} else {
+			cache.Set(key, key)
+		}
+	}
+	assert.Equal(t, cache.Size(), checkBintree(t, tree))
+
+	// the height of an AVL tree is below 1.45 * log2(n + 2)
+	assert.LessOrEqual(t, tree.tip.height, 3*bits.Len(uint(cache.Size()))/2)
+}
+
+// checkBintree checks the links, the order of the keys, the heights and the balance of the nodes
+// and returns the number of the nodes
+func checkBintree(t *testing.T, tree *bintreeStorage[int, int]) int {
+	var check func(node *bintreeStorageItem[int, int]) (int, int)
+	check = func(node *bintreeStorageItem[int, int]) (int, int) {
+		if node == nil {
+			return 0, 0
+		}
+
+		if node.left != nil {
+			assert.Equal(t, node, node.left.parent)
+			assert.Less(t, node.left.key, node.key)
+		}
+
+		if node.right != nil {
+			assert.Equal(t, node, node.right.parent)
+			assert.Greater(t, node.right.key, node.key)
+		}
+
+		leftSize, leftHeight := check(node.left)
+		rightSize, rightHeight := check(node.right)
+		assert.Equal(t, max(leftHeight, rightHeight)+1, node.height, "height of %v", node.key)
+		assert.LessOrEqual(t, leftHeight-rightHeight, 1, "balance of %v", node.key)
+		assert.GreaterOrEqual(t, leftHeight-rightHeight, -1, "balance of %v", node.key)
+
+		return leftSize + rightSize + 1, node.height
+	}
+
+	if tree.tip != nil {
+		assert.Nil(t, tree.tip.parent)
+	}
+
+	size, _ := check(tree.tip)
+	return size
+}
+
+func TestBintreeLRUCache_FloatKeys(t *testing.T) {
+	cache, tree := newTestBintreeLRU[float64, string](10)
+	keys := []float64{1.5, -2, math.Inf(1), 0, math.Inf(-1)}
+	for _, key := range keys {
+		cache.Set(key, fmt.Sprint(key))
+	}
+	assert.Equal(t, len(keys), cache.Size())
+
+	for _, key := range keys {
+		found, value := cache.Get(key)
+		assert.True(t, found, key)
+		assert.Equal(t, fmt.Sprint(key), value)
+	}
+
+	// the policies can't find NaN, so the cache ignores it
+	cache.Set(math.NaN(), "NaN")
+	assert.False(t, cache.Contains(math.NaN()))
+	assert.Equal(t, len(keys), cache.Size())
+
+	// the tree itself orders NaN before the other keys and finds it
+	tree.add(&cacheEntry[float64, string]{key: math.NaN(), value: "NaN"})
+	tree.add(&cacheEntry[float64, string]{key: math.NaN(), value: "another NaN"})
+	assert.Equal(t, len(keys)+1, tree.len())
+	assert.Equal(t, "another NaN", tree.get(math.NaN()).value)
+
+	var ordered []string
+	tree.each(func(entry *cacheEntry[float64, string]) bool {
+		ordered = append(ordered, fmt.Sprint(entry.key))
+		return true
+	})
+	assert.Equal(t, []string{"NaN", "-Inf", "-2", "0", "1.5", "+Inf"}, ordered)
+
+	assert.Equal(t, "another NaN", tree.remove(math.NaN()).value)
+	assert.Nil(t, tree.get(math.NaN()))
+	assert.Equal(t, len(keys), tree.len())
+}
+
+func TestBintreeLRUCache_CustomPolicy(t *testing.T) {
+	testLRUCacheCustomPolicy(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_TTL(t *testing.T) {
+	testLRUCacheTTL(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_ExpireAfterAccess(t *testing.T) {
+	testLRUCacheExpireAfterAccess(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_OnEvict(t *testing.T) {
+	testLRUCacheOnEvict(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_SetAndEvict(t *testing.T) {
+	testLRUCacheSetAndEvict(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Stats(t *testing.T) {
+	testLRUCacheStats(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_TopK(t *testing.T) {
+	testLRUCacheTopK(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Aging(t *testing.T) {
+	testLRUCacheAging(t, NewBintreeLRU[string, string])
+}
+
+func TestBintreeLRUCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewBintreeLRU[int, int])
+}
+
+func TestBintreeLRUCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewBintreeLRU[int, int])
+}
@@ -0,0 +1,487 @@
+package lru
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+	// deadline is the absolute expiration time set by the TTL, it's zero for the entries without TTL
+	deadline time.Time
+	// expiresAt is the earliest of deadline and the idle timeout, it's zero for the entries which never expire
+	expiresAt       time.Time
+	expirationIndex int
+	// readIdleExpiresAt is the end of the idle timeout restarted by the reads of NewConcurrent in Unix nanoseconds.
+	// The reads hold only a shared lock, so they can't move the entry within the expiration queue. The queue catches up
+	// with applyReads under the exclusive lock. It's 0 when there are no such reads
+	readIdleExpiresAt atomic.Int64
+}
+
+func (e *cacheEntry[K, V]) expired(now time.Time) bool {
+	expiresAt := e.expiresAt
+	if readIdleExpiresAt := e.readIdleExpiresAt.Load(); readIdleExpiresAt != 0 {
+		expiresAt = earliest(e.deadline, time.Unix(0, readIdleExpiresAt))
+	}
+
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// restartIdleTimeout records the end of the idle timeout restarted by a read, it's safe for concurrent use
+func (e *cacheEntry[K, V]) restartIdleTimeout(idleExpiresAt time.Time) {
+	for {
+		current := e.readIdleExpiresAt.Load()
+		if idleExpiresAt.UnixNano() <= current || e.readIdleExpiresAt.CompareAndSwap(current, idleExpiresAt.UnixNano()) {
+			return
+		}
+	}
+}
+
+// applyReads moves the idle timeout restarted by the reads to expiresAt and reports whether there were such reads
+func (e *cacheEntry[K, V]) applyReads() bool {
+	readIdleExpiresAt := e.readIdleExpiresAt.Swap(0)
+	if readIdleExpiresAt == 0 {
+		return false
+	}
+
+	e.expiresAt = earliest(e.deadline, time.Unix(0, readIdleExpiresAt))
+	return true
+}
+
+// earliest returns the earliest of the deadline and the end of the idle timeout, a zero deadline means no deadline
+func earliest(deadline, idleExpiresAt time.Time) time.Time {
+	if deadline.IsZero() || idleExpiresAt.Before(deadline) {
+		return idleExpiresAt
+	}
+
+	return deadline
+}
+
+// storage keeps the entries of the cache. It doesn't know anything about their popularity,
+// the eviction order is decided by the Policy of the cache
+type storage[K comparable, V any] interface {
+	get(key K) *cacheEntry[K, V]
+	add(entry *cacheEntry[K, V])
+	remove(key K) *cacheEntry[K, V]
+	len() int
+	clear()
+	// each calls fn for every entry until fn returns false
+	each(fn func(entry *cacheEntry[K, V]) bool)
+	// backend returns the name of the storage used in the metrics
+	backend() string
+}
+
+// cache combines a storage backend with an eviction policy
+type cache[K comparable, V any] struct {
+	capacity    int
+	storage     storage[K, V]
+	policy      Policy[K]
+	expirations expirationQueue[K, V]
+	options     options[K, V]
+	clock       Clock
+	// mu guards the cache from the janitor, it's a real lock only when the janitor is running
+	mu       sync.Locker
+	janitor  *janitor
+	removals removals[K, V]
+	stats    cacheStats
+}
+
+func newCache[K comparable, V any](capacity int, newStorage func(capacity int) storage[K, V], opts []Option[K, V]) *cache[K, V] {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	o := newOptions(opts)
+	policy := o.policy
+	switch {
+	case policy != nil:
+	case o.policyFactory != nil:
+		policy = o.policyFactory(capacity)
+	default:
+		policy = newOrderingPolicy(o, capacity)
+	}
+
+	c := &cache[K, V]{
+		capacity: capacity,
+		storage:  newStorage(capacity),
+		policy:   policy,
+		options:  o,
+		clock:    o.clock,
+		mu:       noLocker{},
+		removals: removals[K, V]{listener: o.onEvict},
+	}
+
+	if o.janitorInterval > 0 {
+		c.mu = &sync.Mutex{}
+		c.janitor = startJanitor(o.clock, o.janitorInterval, func() {
+			c.sweep(o.janitorSweepLimit)
+		})
+	}
+
+	return c
+}
+
+func (c *cache[K, V]) Get(key K) (found bool, value V) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil {
+		c.stats.misses++
+		return false, value
+	}
+
+	c.stats.hits++
+	c.access(entry)
+	return true, entry.value
+}
+
+func (c *cache[K, V]) Set(key K, value V) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, !c.options.keepExistingValues, c.options.ttl)
+}
+
+func (c *cache[K, V]) SetAndEvict(key K, value V) (evictedKey K, evictedValue V, evicted bool) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, _, victim := c.set(key, value, !c.options.keepExistingValues, c.options.ttl); victim != nil {
+		return victim.key, victim.value, true
+	}
+
+	return evictedKey, evictedValue, false
+}
+
+func (c *cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, !c.options.keepExistingValues, ttl)
+}
+
+func (c *cache[K, V]) Replace(key K, value V) (found bool, previous V) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found, previous, _ = c.set(key, value, true, c.options.ttl)
+	return found, previous
+}
+
+// set adds the entry or updates the existing one. It returns the previous value of the key if there was one
+// and the entry evicted to make room for the new one
+func (c *cache[K, V]) set(key K, value V, replace bool, ttl time.Duration) (found bool, previous V, evicted *cacheEntry[K, V]) {
+	if key != key {
+		// a key which isn't equal to itself, like NaN, can't be found in the maps of the policies
+		return false, previous, nil
+	}
+
+	if entry := c.lookup(key); entry != nil {
+		previous = entry.value
+		if replace {
+			c.removed(key, previous, ReasonReplaced)
+			entry.value = value
+			c.expireAfter(entry, ttl)
+		}
+
+		c.access(entry)
+		return true, previous, nil
+	}
+
+	if observer, ok := c.policy.(missObserver[K]); ok {
+		observer.onMiss(key)
+	}
+
+	if c.storage.len() >= c.capacity {
+		evicted = c.evict()
+	}
+
+	entry := &cacheEntry[K, V]{key: key, value: value, expirationIndex: -1}
+	c.storage.add(entry)
+	c.stats.insertions++
+	c.policy.OnInsert(key)
+	c.expireAfter(entry, ttl)
+	return false, previous, evicted
+}
+
+func (c *cache[K, V]) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.storage.len()
+}
+
+func (c *cache[K, V]) Delete(key K) bool {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil {
+		return false
+	}
+
+	c.remove(entry, ReasonDeleted)
+	return true
+}
+
+func (c *cache[K, V]) Peek(key K) (found bool, value V) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil {
+		return false, value
+	}
+
+	return true, entry.value
+}
+
+// touch notifies the policy about a read done by read, the idle timeout of the entry was restarted by the read itself
+func (c *cache[K, V]) touch(key K) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry := c.lookup(key); entry != nil {
+		c.policy.OnAccess(entry.key)
+	}
+}
+
+// peek works like Peek, but it doesn't remove expired entries, so it can be called by several goroutines at once
+func (c *cache[K, V]) peek(key K) (found bool, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.storage.get(key)
+	if entry == nil || entry.expired(c.clock.Now()) {
+		return false, value
+	}
+
+	return true, entry.value
+}
+
+// read works like peek and restarts the idle timeout of the entry. It only records the new end of the idle timeout,
+// so it can be called by several goroutines at once too
+func (c *cache[K, V]) read(key K) (found bool, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	entry := c.storage.get(key)
+	if entry == nil || entry.expired(now) {
+		return false, value
+	}
+
+	if c.options.expireAfterAccess > 0 {
+		entry.restartIdleTimeout(now.Add(c.options.expireAfterAccess))
+	}
+
+	return true, entry.value
+}
+
+func (c *cache[K, V]) Contains(key K) bool {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookup(key) != nil
+}
+
+func (c *cache[K, V]) Clear() {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.storage.each(func(entry *cacheEntry[K, V]) bool {
+		c.policy.OnRemove(entry.key)
+		c.removed(entry.key, entry.value, ReasonCleared)
+		return true
+	})
+	c.storage.clear()
+	clear(c.expirations)
+	c.expirations = c.expirations[:0]
+}
+
+// Close stops the janitor of the cache. The cache stays usable, but expired items are removed only when accessed
+func (c *cache[K, V]) Close() {
+	if c.janitor != nil {
+		c.janitor.close()
+	}
+}
+
+func (c *cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats.snapshot()
+}
+
+func (c *cache[K, V]) limit() int {
+	return c.capacity
+}
+
+func (c *cache[K, V]) backend() string {
+	return c.storage.backend()
+}
+
+func (c *cache[K, V]) deferRemovals() {
+	c.removals.deferred = true
+}
+
+func (c *cache[K, V]) flushRemovals() {
+	c.removals.flush()
+}
+
+func (c *cache[K, V]) TopK(n int) []KeyHits[K] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ranker, ok := c.policy.(popularityRanker[K]); ok {
+		return ranker.topK(n)
+	}
+
+	return nil
+}
+
+func (c *cache[K, V]) Coldest(n int) []KeyHits[K] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ranker, ok := c.policy.(popularityRanker[K]); ok {
+		return ranker.coldest(n)
+	}
+
+	return nil
+}
+
+func (c *cache[K, V]) extractPopularityKeys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if extractor, ok := c.policy.(lruPopularityExtractor[K]); ok {
+		return extractor.extractPopularityKeys()
+	}
+
+	return nil
+}
+
+// evict removes an expired entry if there is one, otherwise it removes the victim of the policy.
+// It returns the removed entry or nil if nothing was removed
+func (c *cache[K, V]) evict() *cacheEntry[K, V] {
+	if c.storage.len() < c.capacity {
+		return nil
+	}
+
+	if entry := c.expirations.peekExpired(c.clock.Now()); entry != nil {
+		c.remove(entry, ReasonExpired)
+		return entry
+	}
+
+	key, ok := c.policy.Victim()
+	if !ok {
+		return nil
+	}
+
+	entry := c.storage.get(key)
+	if entry != nil {
+		c.remove(entry, ReasonCapacity)
+	}
+
+	return entry
+}
+
+// sweep removes up to limit expired entries, there is no limit when limit isn't positive
+func (c *cache[K, V]) sweep(limit int) {
+	defer c.removals.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	for removed := 0; limit <= 0 || removed < limit; removed++ {
+		entry := c.expirations.peekExpired(now)
+		if entry == nil {
+			return
+		}
+
+		c.remove(entry, ReasonExpired)
+	}
+}
+
+// lookup returns the entry of the key. Expired entries are removed and reported as missing
+func (c *cache[K, V]) lookup(key K) *cacheEntry[K, V] {
+	entry := c.storage.get(key)
+	if entry == nil {
+		return nil
+	}
+
+	if entry.applyReads() {
+		c.expirations.update(entry)
+	}
+
+	if entry.expired(c.clock.Now()) {
+		c.remove(entry, ReasonExpired)
+		return nil
+	}
+
+	return entry
+}
+
+func (c *cache[K, V]) remove(entry *cacheEntry[K, V], reason RemovalReason) {
+	c.removed(entry.key, entry.value, reason)
+	c.storage.remove(entry.key)
+	c.policy.OnRemove(entry.key)
+	c.expirations.remove(entry)
+}
+
+// removed counts the removed item and queues the notification about it
+func (c *cache[K, V]) removed(key K, value V, reason RemovalReason) {
+	if reason == ReasonReplaced {
+		c.stats.replacements++
+	} else {
+		c.stats.evictions[reason]++
+	}
+	c.removals.add(key, value, reason)
+}
+
+// access notifies the policy about the access to the entry and extends its idle timeout
+func (c *cache[K, V]) access(entry *cacheEntry[K, V]) {
+	c.policy.OnAccess(entry.key)
+	if c.options.expireAfterAccess > 0 {
+		c.updateExpiration(entry)
+	}
+}
+
+// expireAfter sets the deadline of the entry, the entry has no deadline when ttl isn't positive
+func (c *cache[K, V]) expireAfter(entry *cacheEntry[K, V], ttl time.Duration) {
+	if ttl > 0 {
+		entry.deadline = c.clock.Now().Add(ttl)
+	} else {
+		entry.deadline = time.Time{}
+	}
+
+	c.updateExpiration(entry)
+}
+
+// updateExpiration sets the expiration time of the entry to its deadline or to the end of the idle timeout,
+// whichever comes first
+func (c *cache[K, V]) updateExpiration(entry *cacheEntry[K, V]) {
+	// the idle timeout restarted by the earlier reads ends before the new one
+	entry.readIdleExpiresAt.Store(0)
+
+	entry.expiresAt = entry.deadline
+	if c.options.expireAfterAccess > 0 {
+		entry.expiresAt = earliest(entry.deadline, c.clock.Now().Add(c.options.expireAfterAccess))
+	}
+
+	c.expirations.update(entry)
+}
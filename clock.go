@@ -0,0 +1,25 @@
+package lru
+
+import "time"
+
+// Clock is the source of time of the cache. The real time is used by default, tests can replace it with a fake clock
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// AfterFunc calls f after d has passed. The returned function cancels the call,
+	// it reports false if the call has already happened or has been cancelled
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// SystemClock is the Clock backed by the time package
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls f in its own goroutine after d has passed
+func (SystemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
@@ -0,0 +1,281 @@
+package lru
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const readBufferSize = 64
+
+// readBuffer collects the keys which were read, the buffer drops new keys when it's full
+type readBuffer[K comparable] struct {
+	mu   sync.Mutex
+	keys []K
+	// keep the buffers on different cache lines
+	_ [32]byte
+}
+
+// concurrentReader is implemented by the caches which can be read by several goroutines at once
+type concurrentReader[K comparable, V any] interface {
+	// peek returns the value of the key without changing anything in the cache
+	peek(key K) (bool, V)
+	// read returns the value of the key and restarts its idle timeout, the policy learns about the read from touch
+	read(key K) (bool, V)
+	// touch applies a read which was recorded earlier to the policy
+	touch(key K)
+}
+
+// concurrentLRU lets goroutines read the wrapped cache at the same time. Reads don't update the policy right away,
+// they record the key into one of the striped buffers instead. The buffers are drained into the policy by whoever
+// gets the exclusive lock first: a reader which filled a buffer or any writer
+type concurrentLRU[K comparable, V any] struct {
+	mu      sync.RWMutex
+	lru     LRU[K, V]
+	reader  concurrentReader[K, V]
+	buffers []readBuffer[K]
+	// drained is reused by drain to avoid allocations
+	drained []K
+	// deferred is set when the notifications about removed items are flushed by another wrapper
+	deferred bool
+	// hits and misses count the reads which don't reach the wrapped cache
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewConcurrent wraps the cache, so it can be shared between goroutines. Unlike NewSynchronized it doesn't take
+// an exclusive lock for Get, which makes it scale better for read-mostly workloads. The price is that the policy learns
+// about reads with a delay and may lose some of them when the buffers overflow. Idle timeouts are restarted by the reads
+// right away.
+// Caches which aren't created by the constructors of this package are locked exclusively for every operation.
+// The wrapped cache shouldn't be used directly
+func NewConcurrent[K comparable, V any](lru LRU[K, V]) LRU[K, V] {
+	stripes := 1
+	for stripes < 4*runtime.GOMAXPROCS(0) {
+		stripes *= 2
+	}
+
+	c := &concurrentLRU[K, V]{
+		lru:     lru,
+		buffers: make([]readBuffer[K], stripes),
+		drained: make([]K, 0, readBufferSize),
+	}
+
+	if reader, ok := lru.(concurrentReader[K, V]); ok {
+		c.reader = reader
+	}
+	deferRemovalsOf(lru)
+
+	return c
+}
+
+func (c *concurrentLRU[K, V]) Get(key K) (bool, V) {
+	if c.reader == nil {
+		defer c.notify()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.drain()
+		return c.lru.Get(key)
+	}
+
+	c.mu.RLock()
+	found, value := c.reader.read(key)
+	c.mu.RUnlock()
+
+	if found {
+		c.hits.Add(1)
+		c.record(key)
+	} else {
+		c.misses.Add(1)
+	}
+
+	return found, value
+}
+
+func (c *concurrentLRU[K, V]) Set(key K, value V) {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	c.lru.Set(key, value)
+}
+
+func (c *concurrentLRU[K, V]) SetAndEvict(key K, value V) (K, V, bool) {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.SetAndEvict(key, value)
+}
+
+func (c *concurrentLRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	c.lru.SetWithTTL(key, value, ttl)
+}
+
+func (c *concurrentLRU[K, V]) Replace(key K, value V) (bool, V) {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.Replace(key, value)
+}
+
+func (c *concurrentLRU[K, V]) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Size()
+}
+
+func (c *concurrentLRU[K, V]) Delete(key K) bool {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.Delete(key)
+}
+
+func (c *concurrentLRU[K, V]) Peek(key K) (bool, V) {
+	if c.reader == nil {
+		defer c.notify()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		return c.lru.Peek(key)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.reader.peek(key)
+}
+
+func (c *concurrentLRU[K, V]) Contains(key K) bool {
+	found, _ := c.Peek(key)
+	return found
+}
+
+func (c *concurrentLRU[K, V]) Clear() {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	c.lru.Clear()
+}
+
+func (c *concurrentLRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Stats().merge(Stats{Hits: c.hits.Load(), Misses: c.misses.Load()})
+}
+
+func (c *concurrentLRU[K, V]) Close() {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	c.lru.Close()
+}
+
+func (c *concurrentLRU[K, V]) TopK(n int) []KeyHits[K] {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.TopK(n)
+}
+
+func (c *concurrentLRU[K, V]) Coldest(n int) []KeyHits[K] {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.Coldest(n)
+}
+
+func (c *concurrentLRU[K, V]) extractPopularityKeys() []K {
+	defer c.notify()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drain()
+	return c.lru.extractPopularityKeys()
+}
+
+func (c *concurrentLRU[K, V]) limit() int {
+	return capacityOf(c.lru)
+}
+
+func (c *concurrentLRU[K, V]) backend() string {
+	return backendOf(c.lru)
+}
+
+func (c *concurrentLRU[K, V]) deferRemovals() {
+	c.deferred = true
+}
+
+func (c *concurrentLRU[K, V]) flushRemovals() {
+	flushRemovalsOf(c.lru)
+}
+
+// notify sends the notifications about removed items after the lock is released
+func (c *concurrentLRU[K, V]) notify() {
+	if !c.deferred {
+		flushRemovalsOf(c.lru)
+	}
+}
+
+// record adds the key to a random buffer. When the buffer is full it tries to drain all buffers,
+// but gives up if somebody else holds the lock
+func (c *concurrentLRU[K, V]) record(key K) {
+	buffer := &c.buffers[rand.Uint64()&uint64(len(c.buffers)-1)]
+
+	buffer.mu.Lock()
+	if len(buffer.keys) < readBufferSize {
+		buffer.keys = append(buffer.keys, key)
+	}
+	full := len(buffer.keys) == readBufferSize
+	buffer.mu.Unlock()
+
+	if full && c.mu.TryLock() {
+		c.drain()
+		c.mu.Unlock()
+		c.notify()
+	}
+}
+
+// drain replays the recorded reads on the wrapped cache, it must be called with the exclusive lock
+func (c *concurrentLRU[K, V]) drain() {
+	for i := range c.buffers {
+		buffer := &c.buffers[i]
+
+		buffer.mu.Lock()
+		c.drained = append(c.drained[:0], buffer.keys...)
+		clear(buffer.keys)
+		buffer.keys = buffer.keys[:0]
+		buffer.mu.Unlock()
+
+		for _, key := range c.drained {
+			c.reader.touch(key)
+		}
+	}
+
+	clear(c.drained)
+}
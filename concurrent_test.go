@@ -0,0 +1,183 @@
+package lru
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/melan/go-lru/lrutest"
+)
+
+func TestConcurrent(t *testing.T) {
+	cache := NewConcurrent(NewMapLRU[string, string](3)).(*concurrentLRU[string, string])
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+	cache.Set("c", "value c")
+
+	for i := 0; i < 5; i++ {
+		gotFound, gotValue := cache.Get("c")
+		assert.True(t, gotFound)
+		assert.Equal(t, "value c", gotValue)
+	}
+
+	gotFound, gotValue := cache.Get("d")
+	assert.False(t, gotFound)
+	assert.Empty(t, gotValue)
+
+	// the reads are applied when the buffers are drained
+	assert.Equal(t, []string{"c", "a", "b"}, cache.extractPopularityKeys())
+	assert.Equal(t, []KeyHits[string]{{"c", 6}}, cache.TopK(1))
+	assert.Equal(t, []KeyHits[string]{{"b", 1}}, cache.Coldest(1))
+
+	cache.Set("d", "value d")
+	assert.False(t, cache.Contains("b"))
+	assert.True(t, cache.Contains("c"))
+
+	gotFound, gotPrevious := cache.Replace("d", "new value d")
+	assert.True(t, gotFound)
+	assert.Equal(t, "value d", gotPrevious)
+
+	gotFound, gotValue = cache.Peek("d")
+	assert.True(t, gotFound)
+	assert.Equal(t, "new value d", gotValue)
+
+	assert.True(t, cache.Delete("d"))
+	assert.Equal(t, 2, cache.Size())
+
+	_, _, gotEvicted := cache.SetAndEvict("e", "value e")
+	assert.False(t, gotEvicted)
+	gotKey, gotValue, gotEvicted := cache.SetAndEvict("f", "value f")
+	assert.True(t, gotEvicted)
+	assert.Equal(t, "e", gotKey)
+	assert.Equal(t, "value e", gotValue)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Size())
+	cache.Close()
+}
+
+func TestConcurrent_ReadsAreDrainedWhenBufferIsFull(t *testing.T) {
+	concurrent := NewConcurrent(NewMapLRU[int, int](10)).(*concurrentLRU[int, int])
+	for i := 0; i < 10; i++ {
+		concurrent.Set(i, i)
+	}
+
+	for i := 0; i < len(concurrent.buffers)*readBufferSize*4; i++ {
+		concurrent.Get(9)
+	}
+
+	inner := concurrent.lru.(*cache[int, int])
+	assert.Equal(t, 9, inner.extractPopularityKeys()[0], "reads of the hot key should reach the policy without writes")
+}
+
+func TestConcurrent_Expiration(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewConcurrent(NewMapLRU[string, string](3,
+		WithClock[string, string](clock),
+		WithExpireAfterAccess[string, string](time.Minute),
+	))
+
+	cache.Set("a", "value a")
+	clock.Advance(59 * time.Second)
+	gotFound, _ := cache.Get("a")
+	assert.True(t, gotFound)
+
+	cache.Set("b", "value b")
+	clock.Advance(59 * time.Second)
+	assert.True(t, cache.Contains("a"), "the read should extend the life of the item")
+
+	clock.Advance(time.Minute)
+	gotFound, _ = cache.Get("a")
+	assert.False(t, gotFound)
+}
+
+func TestConcurrent_ExpireAfterAccessWithReadsOnly(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewConcurrent(NewMapLRU[string, string](3,
+		WithClock[string, string](clock),
+		WithExpireAfterAccess[string, string](time.Minute),
+	))
+	cache.Set("a", "value a")
+
+	for i := 0; i < 5; i++ {
+		clock.Advance(50 * time.Second)
+		gotFound, _ := cache.Get("a")
+		assert.True(t, gotFound, "read %d should extend the life of the item", i)
+	}
+
+	// the reads are drained later, which doesn't extend the life of the item once again
+	cache.Set("b", "value b")
+	clock.Advance(time.Minute)
+	gotFound, _ := cache.Get("a")
+	assert.False(t, gotFound)
+}
+
+func TestConcurrent_ExpireAfterAccessEviction(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewConcurrent(NewMapLRU[string, string](2,
+		WithClock[string, string](clock),
+		WithExpireAfterAccess[string, string](time.Minute),
+	))
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+
+	clock.Advance(50 * time.Second)
+	gotFound, _ := cache.Get("a")
+	assert.True(t, gotFound)
+
+	// the expiration queue learns about the read of a only now and evicts b, which has expired
+	clock.Advance(20 * time.Second)
+	gotKey, _, gotEvicted := cache.SetAndEvict("c", "value c")
+	assert.True(t, gotEvicted)
+	assert.Equal(t, "b", gotKey)
+	assert.True(t, cache.Contains("a"))
+}
+
+func TestConcurrent_WithoutConcurrentReader(t *testing.T) {
+	cache := NewConcurrent(NewShardedLRU(2, 2, NewMapLRU[string, string]))
+	cache.Set("a", "value a")
+
+	gotFound, gotValue := cache.Get("a")
+	assert.True(t, gotFound)
+	assert.Equal(t, "value a", gotValue)
+
+	gotFound, gotValue = cache.Peek("a")
+	assert.True(t, gotFound)
+	assert.Equal(t, "value a", gotValue)
+}
+
+func BenchmarkConcurrent(b *testing.B) {
+	const keys = 1 << 16
+
+	wrappers := []struct {
+		name string
+		wrap func(LRU[int, int]) LRU[int, int]
+	}{
+		{name: "synchronized", wrap: NewSynchronized[int, int]},
+		{name: "concurrent", wrap: NewConcurrent[int, int]},
+	}
+
+	for _, wrapper := range wrappers {
+		b.Run(fmt.Sprintf("%s, 95%% reads", wrapper.name), func(b *testing.B) {
+			cache := wrapper.wrap(NewMapLRU[int, int](keys/2, WithOrdering[int, int](LeastRecentlyUsed)))
+			for i := 0; i < keys/2; i++ {
+				cache.Set(i, i)
+			}
+
+			b.RunParallel(func(pb *testing.PB) {
+				rnd := rand.New(rand.NewSource(rand.Int63()))
+				for pb.Next() {
+					key := rnd.Intn(keys)
+					if rnd.Intn(20) == 0 {
+						cache.Set(key, key)
+					} else {
+						cache.Get(key)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package lru
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expirationQueue is a min-heap of the entries ordered by their expiration time.
+// Entries without expiration time aren't kept in the queue
+type expirationQueue[K comparable, V any] []*cacheEntry[K, V]
+
+func (q expirationQueue[K, V]) Len() int {
+	return len(q)
+}
+
+func (q expirationQueue[K, V]) Less(i, j int) bool {
+	return q[i].expiresAt.Before(q[j].expiresAt)
+}
+
+func (q expirationQueue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].expirationIndex = i
+	q[j].expirationIndex = j
+}
+
+func (q *expirationQueue[K, V]) Push(x any) {
+	entry := x.(*cacheEntry[K, V])
+	entry.expirationIndex = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *expirationQueue[K, V]) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+
+	entry.expirationIndex = -1
+	return entry
+}
+
+// update puts the entry into the queue, moves it within the queue or removes it from the queue
+// depending on its expiration time
+func (q *expirationQueue[K, V]) update(entry *cacheEntry[K, V]) {
+	switch {
+	case entry.expiresAt.IsZero() && entry.expirationIndex >= 0:
+		heap.Remove(q, entry.expirationIndex)
+	case entry.expiresAt.IsZero():
+	case entry.expirationIndex >= 0:
+		heap.Fix(q, entry.expirationIndex)
+	default:
+		heap.Push(q, entry)
+	}
+}
+
+func (q *expirationQueue[K, V]) remove(entry *cacheEntry[K, V]) {
+	if entry.expirationIndex >= 0 {
+		heap.Remove(q, entry.expirationIndex)
+	}
+}
+
+// peekExpired returns the entry which has expired first or nil if there are no expired entries.
+// The entries read by NewConcurrent are moved to their places in the queue first
+func (q *expirationQueue[K, V]) peekExpired(now time.Time) *cacheEntry[K, V] {
+	for len(*q) > 0 && (*q)[0].applyReads() {
+		heap.Fix(q, 0)
+	}
+
+	if len(*q) == 0 || !(*q)[0].expired(now) {
+		return nil
+	}
+
+	return (*q)[0]
+}
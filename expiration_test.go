@@ -0,0 +1,41 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpirationQueue(t *testing.T) {
+	now := time.Now()
+	var queue expirationQueue[string, string]
+
+	entries := map[string]*cacheEntry[string, string]{}
+	for i, key := range []string{"c", "a", "never", "b", "d"} {
+		entry := &cacheEntry[string, string]{key: key, expirationIndex: -1}
+		if key != "never" {
+			entry.expiresAt = now.Add(time.Duration(i) * time.Minute)
+		}
+		entries[key] = entry
+		queue.update(entry)
+	}
+
+	assert.Equal(t, 4, queue.Len())
+	assert.Equal(t, -1, entries["never"].expirationIndex)
+	assert.Nil(t, queue.peekExpired(now.Add(-time.Minute)))
+	assert.Equal(t, "c", queue.peekExpired(now).key)
+
+	entries["c"].expiresAt = now.Add(time.Hour)
+	queue.update(entries["c"])
+	assert.Equal(t, "a", queue.peekExpired(now.Add(time.Hour)).key)
+
+	queue.remove(entries["a"])
+	assert.Equal(t, -1, entries["a"].expirationIndex)
+	assert.Equal(t, "b", queue.peekExpired(now.Add(time.Hour)).key)
+
+	entries["b"].expiresAt = time.Time{}
+	queue.update(entries["b"])
+	assert.Equal(t, "d", queue.peekExpired(now.Add(time.Hour)).key)
+	assert.Equal(t, 2, queue.Len())
+}
@@ -0,0 +1,94 @@
+package lru
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// limiter is implemented by the caches which know their capacity
+type limiter interface {
+	limit() int
+}
+
+// capacityOf returns the capacity of the cache, it's 0 for the caches created outside the package
+func capacityOf(cache any) int {
+	if l, ok := cache.(limiter); ok {
+		return l.limit()
+	}
+
+	return 0
+}
+
+// expvarCache is the JSON representation of a cache published with PublishExpvar
+type expvarCache struct {
+	Backend       string            `json:"backend"`
+	Size          int               `json:"size"`
+	Capacity      int               `json:"capacity"`
+	Hits          uint64            `json:"hits"`
+	Misses        uint64            `json:"misses"`
+	HitRatio      float64           `json:"hit_ratio"`
+	Insertions    uint64            `json:"insertions"`
+	Evictions     map[string]uint64 `json:"evictions"`
+	Replacements  uint64            `json:"replacements"`
+	LoadSuccesses uint64            `json:"load_successes"`
+	LoadFailures  uint64            `json:"load_failures"`
+	LoadSeconds   float64           `json:"load_seconds"`
+	TopKeys       []expvarKey       `json:"top_keys"`
+}
+
+type expvarKey struct {
+	Key  string `json:"key"`
+	Hits int    `json:"hits"`
+}
+
+// expvarMu makes the check of the name and the publishing atomic, expvar.Publish panics on duplicate names
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the statistics, the size, the capacity and the topN most popular keys of the cache
+// with the expvar package, so they show up in /debug/vars. The values are collected every time the variable is read.
+// The keys are formatted with fmt.Sprint and come with their hits. It returns an error if the name is already in use
+func PublishExpvar[K comparable, V any](name string, cache LRU[K, V], topN int) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() any {
+		return newExpvarCache(cache, topN)
+	}))
+	return nil
+}
+
+func newExpvarCache[K comparable, V any](cache LRU[K, V], topN int) expvarCache {
+	stats := cache.Stats()
+
+	evictions := make(map[string]uint64, len(evictionReasons))
+	for _, reason := range evictionReasons {
+		evictions[reason.String()] = stats.Evictions[reason]
+	}
+
+	keys := cache.TopK(topN)
+	topKeys := make([]expvarKey, len(keys))
+	for i, key := range keys {
+		topKeys[i] = expvarKey{Key: fmt.Sprint(key.Key), Hits: key.Hits}
+	}
+
+	return expvarCache{
+		Backend:       backendOf(cache),
+		Size:          cache.Size(),
+		Capacity:      capacityOf(cache),
+		Hits:          stats.Hits,
+		Misses:        stats.Misses,
+		HitRatio:      stats.HitRatio(),
+		Insertions:    stats.Insertions,
+		Evictions:     evictions,
+		Replacements:  stats.Replacements,
+		LoadSuccesses: stats.LoadSuccesses,
+		LoadFailures:  stats.LoadFailures,
+		LoadSeconds:   stats.LoadTime.Seconds(),
+		TopKeys:       topKeys,
+	}
+}
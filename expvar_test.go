@@ -0,0 +1,102 @@
+package lru
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expvarNames makes the names published by the tests unique, the expvar registry lives as long as the process
+var expvarNames atomic.Int64
+
+func uniqueExpvarName(t *testing.T) string {
+	return fmt.Sprintf("%s_%d", t.Name(), expvarNames.Add(1))
+}
+
+func readExpvar(t *testing.T, name string) expvarCache {
+	t.Helper()
+
+	var got expvarCache
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &got))
+	return got
+}
+
+func TestPublishExpvar(t *testing.T) {
+	name := uniqueExpvarName(t)
+	cache := NewSynchronized(NewMapLRU[int, string](3))
+	assert.NoError(t, PublishExpvar(name, cache, 2))
+	assert.Error(t, PublishExpvar(name, cache, 2), "names should be unique")
+
+	cache.Set(1, "value")
+	got := readExpvar(t, name)
+	assert.Equal(t, "map", got.Backend)
+	assert.Equal(t, 1, got.Size)
+	assert.Equal(t, 3, got.Capacity)
+	assert.Equal(t, []expvarKey{{Key: "1", Hits: 1}}, got.TopKeys)
+}
+
+func TestPublishExpvar_SameNameConcurrently(t *testing.T) {
+	name := uniqueExpvarName(t)
+	cache := NewSynchronized(NewMapLRU[int, string](3))
+
+	var wg sync.WaitGroup
+	var published atomic.Int64
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if PublishExpvar(name, cache, 2) == nil {
+				published.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), published.Load())
+}
+
+func TestNewExpvarCache(t *testing.T) {
+	cache := NewSynchronized(NewMapLRU[int, string](3))
+
+	got := newExpvarCache(cache, 2)
+	assert.Equal(t, "map", got.Backend)
+	assert.Equal(t, 0, got.Size)
+	assert.Equal(t, 3, got.Capacity)
+	assert.Empty(t, got.TopKeys)
+
+	for key := 1; key <= 4; key++ {
+		cache.Set(key, "value")
+		for i := 0; i < key; i++ {
+			cache.Get(key)
+		}
+	}
+	cache.Get(5)
+
+	got = newExpvarCache(cache, 2)
+	assert.Equal(t, 3, got.Size)
+	assert.Equal(t, uint64(10), got.Hits)
+	assert.Equal(t, uint64(1), got.Misses)
+	assert.Equal(t, 10.0/11, got.HitRatio)
+	assert.Equal(t, uint64(4), got.Insertions)
+	assert.Equal(t, map[string]uint64{"capacity": 1, "expired": 0, "deleted": 0, "cleared": 0}, got.Evictions)
+	assert.Zero(t, got.Replacements)
+	assert.Equal(t, []expvarKey{{Key: "4", Hits: 5}, {Key: "3", Hits: 4}}, got.TopKeys)
+}
+
+func TestNewExpvarCache_LoadingCache(t *testing.T) {
+	cache := NewLoadingCache(NewShardedLRU(4, 8, NewBintreeLRU[string, string]))
+	cache.loadFailures.Add(1)
+	cache.Set("a", "value a")
+
+	got := newExpvarCache[string, string](cache, 0)
+	assert.Equal(t, "bintree", got.Backend)
+	assert.Equal(t, 1, got.Size)
+	assert.Equal(t, 32, got.Capacity)
+	assert.Equal(t, uint64(1), got.LoadFailures)
+	assert.Empty(t, got.TopKeys)
+}
@@ -0,0 +1,100 @@
+package lru
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// zipfTrace generates the keys of a skewed workload. Every scanEvery keys a scan of scanLength unique keys is added
+func zipfTrace(length, keys, scanEvery, scanLength int) []int {
+	rnd := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rnd, 1.1, 1, uint64(keys-1))
+
+	trace := make([]int, 0, length)
+	scanKey := keys
+	for len(trace) < length {
+		trace = append(trace, int(zipf.Uint64()))
+		if scanEvery > 0 && len(trace)%scanEvery == 0 {
+			for i := 0; i < scanLength; i++ {
+				trace = append(trace, scanKey)
+				scanKey++
+			}
+		}
+	}
+
+	return trace
+}
+
+// loopTrace repeats the keys from 0 to loop-1 until the trace has the given length
+func loopTrace(length, loop int) []int {
+	trace := make([]int, length)
+	for i := range trace {
+		trace[i] = i % loop
+	}
+
+	return trace
+}
+
+// replay reads the keys of the trace from the cache, adding the missing ones, and returns the hit ratio
+func replay(cache LRU[int, int], trace []int) float64 {
+	for _, key := range trace {
+		if found, _ := cache.Get(key); !found {
+			cache.Set(key, key)
+		}
+	}
+
+	return cache.Stats().HitRatio()
+}
+
+// hitRatioTrace generates its keys on the first use, so the test binary doesn't pay for the traces it doesn't replay
+type hitRatioTrace struct {
+	name string
+	keys func() []int
+}
+
+var (
+	zipfHitRatioTrace = hitRatioTrace{"zipf", sync.OnceValue(func() []int {
+		return zipfTrace(100000, 10000, 0, 0)
+	})}
+	zipfWithScansHitRatioTrace = hitRatioTrace{"zipf with scans", sync.OnceValue(func() []int {
+		return zipfTrace(100000, 10000, 1000, 500)
+	})}
+	loopHitRatioTrace = hitRatioTrace{"loop", sync.OnceValue(func() []int {
+		return loopTrace(100000, 250)
+	})}
+
+	hitRatioTraces = []hitRatioTrace{zipfHitRatioTrace, zipfWithScansHitRatioTrace, loopHitRatioTrace}
+)
+
+var hitRatioCaches = []struct {
+	name     string
+	newCache Factory[int, int]
+	opts     []Option[int, int]
+}{
+	{"map lfu", NewMapLRU[int, int], nil},
+	{"map lru", NewMapLRU[int, int], []Option[int, int]{WithOrdering[int, int](LeastRecentlyUsed)}},
+	{"arc", NewARCCache[int, int], nil},
+	{"2q", NewTwoQueueCache[int, int], nil},
+	{"tinylfu", NewTinyLFUCache[int, int], nil},
+	{"tinylfu with doorkeeper", NewTinyLFUCache[int, int], []Option[int, int]{WithDoorkeeper[int, int]()}},
+	{"lirs", NewLIRSCache[int, int], nil},
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	for _, trace := range hitRatioTraces {
+		for _, c := range hitRatioCaches {
+			b.Run(trace.name+"/"+c.name, func(b *testing.B) {
+				keys := trace.keys()
+				b.ResetTimer()
+
+				var hitRatio float64
+				for i := 0; i < b.N; i++ {
+					hitRatio = replay(c.newCache(200, c.opts...), keys)
+				}
+
+				b.ReportMetric(100*hitRatio, "hit%")
+			})
+		}
+	}
+}
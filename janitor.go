@@ -0,0 +1,63 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// janitor periodically calls sweep in the background until it's closed
+type janitor struct {
+	clock    Clock
+	interval time.Duration
+	sweep    func()
+
+	mu      sync.Mutex
+	stop    func() bool
+	stopped bool
+}
+
+func startJanitor(clock Clock, interval time.Duration, sweep func()) *janitor {
+	j := &janitor{
+		clock:    clock,
+		interval: interval,
+		sweep:    sweep,
+	}
+	j.schedule()
+
+	return j
+}
+
+func (j *janitor) schedule() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped {
+		return
+	}
+
+	j.stop = j.clock.AfterFunc(j.interval, j.run)
+}
+
+func (j *janitor) run() {
+	j.sweep()
+	j.schedule()
+}
+
+// close cancels the next sweep, a sweep which is already running isn't interrupted
+func (j *janitor) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped {
+		return
+	}
+
+	j.stopped = true
+	j.stop()
+}
+
+// noLocker is used by caches which aren't accessed by background goroutines
+type noLocker struct{}
+
+func (noLocker) Lock()   {}
+func (noLocker) Unlock() {}
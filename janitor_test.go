@@ -0,0 +1,96 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/melan/go-lru/lrutest"
+)
+
+func TestCache_sweep(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantSizes []int
+	}{
+		{
+			name:      "no limit",
+			limit:     0,
+			wantSizes: []int{2, 2},
+		},
+		{
+			name:      "limited sweeps",
+			limit:     3,
+			wantSizes: []int{6, 3, 2, 2},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			clock := lrutest.NewFakeClock(time.Now())
+			cache := NewMapLRU[string, string](20, WithClock[string, string](clock)).(*cache[string, string])
+
+			for i := 0; i < 8; i++ {
+				cache.SetWithTTL(strconv.Itoa(i), "", time.Duration(i+1)*time.Minute)
+			}
+			cache.Set("forever", "")
+
+			clock.Advance(7 * time.Minute)
+			for _, wantSize := range tt.wantSizes {
+				cache.sweep(tt.limit)
+				assert.Equal(t, wantSize, cache.Size())
+			}
+
+			assert.Equal(t, []string{"7", "forever"}, cache.extractPopularityKeys())
+		})
+	}
+}
+
+func TestCache_Janitor(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewMapLRU[int, int](10,
+		WithTTL[int, int](time.Minute),
+		WithJanitor[int, int](10*time.Second, 2),
+		WithClock[int, int](clock),
+	)
+
+	for i := 0; i < 5; i++ {
+		cache.Set(i, i)
+	}
+	cache.SetWithTTL(5, 5, 0)
+
+	clock.Advance(59 * time.Second)
+	assert.Equal(t, 6, cache.Size())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 4, cache.Size(), "a single sweep removes at most 2 items")
+
+	clock.Advance(20 * time.Second)
+	assert.Equal(t, 1, cache.Size())
+
+	cache.Close()
+	cache.Close()
+
+	cache.SetWithTTL(6, 6, time.Second)
+	clock.Advance(time.Hour)
+	assert.Equal(t, 2, cache.Size(), "janitor shouldn't run after Close")
+	assert.True(t, cache.Contains(5), "cache has to be usable after Close")
+	assert.False(t, cache.Contains(6))
+}
+
+func TestCache_JanitorWithSystemClock(t *testing.T) {
+	cache := NewMapLRU[int, int](10, WithTTL[int, int](time.Millisecond), WithJanitor[int, int](time.Millisecond, 0))
+	defer cache.Close()
+
+	for i := 0; i < 5; i++ {
+		cache.Set(i, i)
+	}
+
+	assert.Eventually(t, func() bool {
+		return cache.Size() == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,68 @@
+package lru
+
+// lfuPolicy orders the keys by the number of hits. New keys start at the tail with a single hit
+// and move towards the head when they get more hits than their neighbours
+type lfuPolicy[K comparable] struct {
+	nodes map[K]*popularityNode[K]
+	list  popularityList[K]
+}
+
+// NewLFUPolicy creates a policy which evicts the least frequently used key
+func NewLFUPolicy[K comparable]() Policy[K] {
+	return &lfuPolicy[K]{nodes: make(map[K]*popularityNode[K])}
+}
+
+// NewAgingLFUPolicy creates a policy which evicts the least frequently used key and halves the hits of all keys
+// after every period accesses, so keys which were popular long ago don't stay in the cache forever
+func NewAgingLFUPolicy[K comparable](period int) Policy[K] {
+	p := &lfuPolicy[K]{nodes: make(map[K]*popularityNode[K])}
+	p.list.agingPeriod = period
+	return p
+}
+
+func (p *lfuPolicy[K]) OnInsert(key K) {
+	if _, ok := p.nodes[key]; ok {
+		p.OnAccess(key)
+		return
+	}
+
+	p.list.age()
+	node := &popularityNode[K]{key: key, hits: 1}
+	p.nodes[key] = node
+	p.list.pushBack(node)
+}
+
+func (p *lfuPolicy[K]) OnAccess(key K) {
+	p.list.age()
+	if node, ok := p.nodes[key]; ok {
+		node.hits++
+		p.list.swap(node)
+	}
+}
+
+func (p *lfuPolicy[K]) OnRemove(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.list.unlink(node)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *lfuPolicy[K]) Victim() (key K, found bool) {
+	if p.list.popularityTail == nil {
+		return key, false
+	}
+
+	return p.list.popularityTail.key, true
+}
+
+func (p *lfuPolicy[K]) topK(n int) []KeyHits[K] {
+	return p.list.topK(n)
+}
+
+func (p *lfuPolicy[K]) coldest(n int) []KeyHits[K] {
+	return p.list.coldest(n)
+}
+
+func (p *lfuPolicy[K]) extractPopularityKeys() []K {
+	return p.list.extractPopularityKeys()
+}
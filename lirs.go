@@ -0,0 +1,288 @@
+package lru
+
+/*
+	Low Inter-reference Recency Set (Jiang and Zhang, 2002).
+
+	The keys are ranked by their inter-reference recency, the number of other keys used between the last two accesses
+	to the key. Most of the cache keeps the LIR keys, which have the lowest recency, and the rest keeps the resident HIR
+	keys. The recency stack S keeps the LIR keys and the HIR keys used more recently than the oldest LIR key,
+	including the non-resident ones, which were evicted but are still remembered. The queue Q keeps the resident
+	HIR keys and the victim is always taken from it, so a loop bigger than the cache can't push the LIR keys out.
+	An HIR key used again while it's in S has a lower recency than the oldest LIR key, so they swap their statuses.
+
+	All lists keep the most recently used key at the head
+*/
+
+const (
+	// lirsHIRPercent is the share of the capacity for the resident HIR keys
+	lirsHIRPercent = 1
+)
+
+type lirsStatus int
+
+const (
+	lirsLIR lirsStatus = iota
+	lirsHIR
+	// lirsNonResident is an HIR key which was evicted but is still in S
+	lirsNonResident
+)
+
+type lirsEntry[K comparable] struct {
+	// stack is the node of the key in S, it keeps the hits of the key
+	stack popularityNode[K]
+	// queue is the node of the key in Q for the resident HIR keys and in the list of the non-resident keys otherwise
+	queue   popularityNode[K]
+	status  lirsStatus
+	inStack bool
+	inQueue bool
+}
+
+type lirsPolicy[K comparable] struct {
+	lirSize int
+	// nonResidentSize limits the number of the non-resident keys, the oldest ones are forgotten
+	nonResidentSize int
+	lirs            int
+	entries         map[K]*lirsEntry[K]
+	stack           popularityList[K]
+	queue           popularityList[K]
+	nonResident     popularityList[K]
+	// victim is the last key returned by Victim, it stays in S as a non-resident key when it's removed
+	victim    K
+	hasVictim bool
+}
+
+// NewLIRSPolicy creates a LIRS policy for the cache with the given capacity. 1% of the capacity, but at least one key,
+// is left for the resident HIR keys, and the policy remembers up to capacity evicted keys
+func NewLIRSPolicy[K comparable](capacity int) Policy[K] {
+	capacity = max(capacity, 1)
+	hirSize := max(capacity*lirsHIRPercent/100, 1)
+
+	return &lirsPolicy[K]{
+		lirSize:         capacity - hirSize,
+		nonResidentSize: capacity,
+		entries:         make(map[K]*lirsEntry[K]),
+	}
+}
+
+// NewLIRSCache creates a LIRS cache with a map as a backend. WithOrdering and WithAging don't apply to it
+func NewLIRSCache[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return NewMapLRU(capacity, append(opts, WithOrdering[K, V](LowInterReferenceRecency))...)
+}
+
+// onMiss takes the new key out of the list of the non-resident keys before the cache evicts something,
+// so the eviction can't make the policy forget it
+func (p *lirsPolicy[K]) onMiss(key K) {
+	if entry, ok := p.entries[key]; ok && entry.status == lirsNonResident && entry.inQueue {
+		p.nonResident.unlink(&entry.queue)
+		entry.inQueue = false
+	}
+}
+
+func (p *lirsPolicy[K]) OnInsert(key K) {
+	entry, ok := p.entries[key]
+	switch {
+	case ok && entry.status != lirsNonResident:
+		p.OnAccess(key)
+		return
+
+	case !ok:
+		entry = &lirsEntry[K]{stack: popularityNode[K]{key: key}, queue: popularityNode[K]{key: key}}
+		p.entries[key] = entry
+	}
+
+	entry.stack.hits = 1
+	if ok || p.lirs < p.lirSize {
+		// the key came back while it's in S, so its recency is lower than the one of the oldest LIR key
+		p.unlinkQueue(entry)
+		p.promote(entry)
+		return
+	}
+
+	entry.status = lirsHIR
+	p.pushStack(entry)
+	p.pushQueue(entry)
+}
+
+func (p *lirsPolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.status == lirsNonResident {
+		return
+	}
+
+	entry.stack.hits++
+	switch {
+	case entry.status == lirsLIR:
+		p.pushStack(entry)
+		p.prune()
+
+	case entry.inStack:
+		p.unlinkQueue(entry)
+		p.promote(entry)
+
+	default:
+		// the key is used for the second time after the oldest LIR key, so it stays an HIR key
+		p.pushStack(entry)
+		p.queue.moveToFront(&entry.queue)
+	}
+}
+
+// OnRemove keeps the victim in S as a non-resident key, the keys removed for other reasons are forgotten
+func (p *lirsPolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.status == lirsNonResident {
+		return
+	}
+
+	victim := p.hasVictim && p.victim == key
+	p.hasVictim = false
+
+	if victim && entry.status == lirsHIR && entry.inStack {
+		p.unlinkQueue(entry)
+		entry.status = lirsNonResident
+		p.pushQueue(entry)
+		for p.nonResident.size > p.nonResidentSize {
+			p.forget(p.entries[p.nonResident.popularityTail.key])
+		}
+		return
+	}
+
+	if entry.status == lirsLIR {
+		p.lirs--
+	}
+	p.forget(entry)
+	p.prune()
+}
+
+// Victim returns the oldest resident HIR key. There are no HIR keys only when the cache is smaller than the LIR set,
+// then the oldest LIR key is returned
+func (p *lirsPolicy[K]) Victim() (key K, found bool) {
+	node := p.queue.popularityTail
+	if node == nil {
+		node = p.stack.popularityTail
+	}
+
+	if node == nil {
+		return key, false
+	}
+
+	p.victim, p.hasVictim = node.key, true
+	return p.victim, true
+}
+
+// promote makes the key an LIR key and turns the oldest LIR keys into HIR ones when the LIR set is over its size
+func (p *lirsPolicy[K]) promote(entry *lirsEntry[K]) {
+	entry.status = lirsLIR
+	p.lirs++
+	p.pushStack(entry)
+	p.prune()
+
+	for p.lirs > p.lirSize && p.stack.popularityTail != nil {
+		bottom := p.entries[p.stack.popularityTail.key]
+		bottom.status = lirsHIR
+		p.lirs--
+		p.stack.unlink(&bottom.stack)
+		bottom.inStack = false
+		p.pushQueue(bottom)
+		p.prune()
+	}
+}
+
+// prune removes the HIR keys from the bottom of S, so the oldest key in S is always an LIR key.
+// The non-resident keys are forgotten
+func (p *lirsPolicy[K]) prune() {
+	for p.stack.popularityTail != nil {
+		entry := p.entries[p.stack.popularityTail.key]
+		if entry.status == lirsLIR {
+			return
+		}
+
+		if entry.status == lirsNonResident {
+			p.forget(entry)
+			continue
+		}
+
+		p.stack.unlink(&entry.stack)
+		entry.inStack = false
+	}
+}
+
+// pushStack moves the key to the top of S
+func (p *lirsPolicy[K]) pushStack(entry *lirsEntry[K]) {
+	if entry.inStack {
+		p.stack.moveToFront(&entry.stack)
+		return
+	}
+
+	entry.inStack = true
+	p.stack.pushFront(&entry.stack)
+}
+
+// queueOf returns the list the queue node of the key belongs to
+func (p *lirsPolicy[K]) queueOf(entry *lirsEntry[K]) *popularityList[K] {
+	if entry.status == lirsNonResident {
+		return &p.nonResident
+	}
+
+	return &p.queue
+}
+
+func (p *lirsPolicy[K]) pushQueue(entry *lirsEntry[K]) {
+	entry.inQueue = true
+	p.queueOf(entry).pushFront(&entry.queue)
+}
+
+func (p *lirsPolicy[K]) unlinkQueue(entry *lirsEntry[K]) {
+	if entry.inQueue {
+		p.queueOf(entry).unlink(&entry.queue)
+		entry.inQueue = false
+	}
+}
+
+func (p *lirsPolicy[K]) forget(entry *lirsEntry[K]) {
+	if entry.inStack {
+		p.stack.unlink(&entry.stack)
+	}
+	p.unlinkQueue(entry)
+	delete(p.entries, entry.stack.key)
+}
+
+// topK returns the LIR keys in the order of S followed by the resident HIR keys in the order of Q
+func (p *lirsPolicy[K]) topK(n int) []KeyHits[K] {
+	keys := make([]KeyHits[K], 0, min(max(n, 0), p.lirs+p.queue.size))
+	for node := p.stack.popularityHead; node != nil && len(keys) < n; node = node.lessPopularNode {
+		if p.entries[node.key].status == lirsLIR {
+			keys = append(keys, KeyHits[K]{Key: node.key, Hits: node.hits})
+		}
+	}
+
+	for node := p.queue.popularityHead; node != nil && len(keys) < n; node = node.lessPopularNode {
+		keys = append(keys, KeyHits[K]{Key: node.key, Hits: p.entries[node.key].stack.hits})
+	}
+
+	return keys
+}
+
+// coldest returns the keys in the reverse order of topK
+func (p *lirsPolicy[K]) coldest(n int) []KeyHits[K] {
+	keys := make([]KeyHits[K], 0, min(max(n, 0), p.lirs+p.queue.size))
+	for node := p.queue.popularityTail; node != nil && len(keys) < n; node = node.morePopularNode {
+		keys = append(keys, KeyHits[K]{Key: node.key, Hits: p.entries[node.key].stack.hits})
+	}
+
+	for node := p.stack.popularityTail; node != nil && len(keys) < n; node = node.morePopularNode {
+		if p.entries[node.key].status == lirsLIR {
+			keys = append(keys, KeyHits[K]{Key: node.key, Hits: node.hits})
+		}
+	}
+
+	return keys
+}
+
+func (p *lirsPolicy[K]) extractPopularityKeys() []K {
+	keys := make([]K, 0, p.lirs+p.queue.size)
+	for _, key := range p.topK(p.lirs + p.queue.size) {
+		keys = append(keys, key.Key)
+	}
+
+	return keys
+}
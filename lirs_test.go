@@ -0,0 +1,126 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLIRSCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewLIRSCache[int, int])
+}
+
+func TestLIRSCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewLIRSCache[int, int])
+}
+
+func TestLIRSCache_Sets(t *testing.T) {
+	cache := NewLIRSCache[string, string](5).(*cache[string, string])
+	policy := cache.policy.(*lirsPolicy[string])
+	assert.Equal(t, 4, policy.lirSize)
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		cache.Set(key, "value "+key)
+	}
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, policy.stack.extractPopularityKeys())
+	assert.Equal(t, []string{"e"}, policy.queue.extractPopularityKeys(), "the LIR set is full, so e is an HIR key")
+
+	// the victim is the oldest resident HIR key, it stays in S as a non-resident key
+	cache.Set("f", "value f")
+	assert.False(t, cache.Contains("e"))
+	assert.Equal(t, []string{"e"}, policy.nonResident.extractPopularityKeys())
+	assert.Equal(t, []string{"f", "e", "d", "c", "b", "a"}, policy.stack.extractPopularityKeys())
+
+	// a non-resident key which comes back becomes an LIR key and the oldest LIR key becomes an HIR one
+	cache.Set("e", "value e")
+	assert.Equal(t, []string{"e", "f", "d", "c", "b"}, policy.stack.extractPopularityKeys())
+	assert.Equal(t, []string{"a"}, policy.queue.extractPopularityKeys())
+	assert.Equal(t, []string{"f"}, policy.nonResident.extractPopularityKeys())
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, cache.extractPopularityKeys())
+
+	// the oldest LIR key moves to the top of S and the HIR keys below the new oldest one are pruned
+	cache.Get("b")
+	assert.Equal(t, []string{"b", "e", "f", "d", "c"}, policy.stack.extractPopularityKeys())
+
+	// an HIR key out of S stays an HIR key, the next access while it's in S promotes it
+	cache.Get("a")
+	assert.Equal(t, []string{"a"}, policy.queue.extractPopularityKeys())
+	cache.Get("a")
+	assert.Equal(t, []string{"a", "b", "e", "f", "d"}, policy.stack.extractPopularityKeys())
+	assert.Equal(t, []string{"a", "b", "e", "d", "c"}, cache.extractPopularityKeys())
+	assert.Equal(t, []KeyHits[string]{{"c", 1}}, cache.Coldest(1))
+	assert.Equal(t, []KeyHits[string]{{"a", 3}}, cache.TopK(1))
+
+	// an HIR key evicted out of S is forgotten
+	cache.Set("f", "value f")
+	assert.False(t, cache.Contains("c"))
+	assert.NotContains(t, policy.entries, "c")
+	assert.Equal(t, []string{"f", "a", "b", "e", "d"}, cache.extractPopularityKeys())
+
+	// a removed LIR key frees a place in the LIR set
+	cache.Delete("a")
+	cache.Set("g", "value g")
+	assert.Equal(t, []string{"g", "f", "b", "e"}, policy.stack.extractPopularityKeys())
+	assert.Equal(t, []string{"g", "f", "b", "e", "d"}, cache.extractPopularityKeys())
+
+	cache.Clear()
+	assert.Empty(t, policy.entries)
+	assert.Zero(t, policy.lirs)
+}
+
+// TestLIRSCache_Loop checks that a loop a bit bigger than the cache keeps most of its keys in the cache,
+// while LRU misses on every access
+func TestLIRSCache_Loop(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		loop     int
+	}{
+		{"loop over 101 keys", 100, 101},
+		{"loop over 150 keys", 100, 150},
+		{"loop over 3 keys", 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := loopTrace(50*tt.loop, tt.loop)
+			lru := replay(NewMapLRU(tt.capacity, WithOrdering[int, int](LeastRecentlyUsed)), trace)
+			assert.Zero(t, lru)
+
+			lirsCache := NewLIRSCache[int, int](tt.capacity)
+			lirs := replay(lirsCache, trace)
+			policy := lirsCache.(*cache[int, int]).policy.(*lirsPolicy[int])
+			assert.Greater(t, lirs, 0.9*float64(policy.lirSize)/float64(tt.loop))
+			assert.LessOrEqual(t, len(policy.entries), 2*tt.capacity)
+		})
+	}
+}
+
+// TestLIRSCache_LoopWithHotKeys checks that the keys of a loop don't push out the keys which are used between them
+func TestLIRSCache_LoopWithHotKeys(t *testing.T) {
+	cache := NewLIRSCache[int, int](100)
+	hot := []int{-1, -2, -3, -4, -5}
+	for round := 0; round < 20; round++ {
+		for key := 0; key < 500; key++ {
+			cache.Set(key, key)
+			if key%50 == 0 {
+				for _, key := range hot {
+					cache.Set(key, key)
+				}
+			}
+		}
+	}
+
+	for _, key := range hot {
+		assert.True(t, cache.Contains(key), key)
+	}
+}
+
+func TestLIRSCache_HitRatio(t *testing.T) {
+	for _, trace := range hitRatioTraces {
+		lru := replay(NewMapLRU(200, WithOrdering[int, int](LeastRecentlyUsed)), trace.keys())
+		lirs := replay(NewLIRSCache[int, int](200), trace.keys())
+
+		assert.Greater(t, lirs, lru, trace.name)
+	}
+}
@@ -1,92 +1,79 @@
 package lru
 
 /*
-	List-based implementation of the LRU cache. It increment hits for gets and for sets, meaning the most popular items will be on top of the list.
+	List-based storage of the LRU cache. It keeps the entries in a slice in the order they were added and looks them up
+	with a linear scan, which is fast enough for small caches.
 
 	This implementation isn't safe when accessed concurrently
 */
 
-type listLRUItem struct {
-	key   string
-	value interface{}
-	hits  int
+type listStorage[K comparable, V any] struct {
+	cache []*cacheEntry[K, V]
 }
 
-type listLRU struct {
-	cache []listLRUItem
+// NewListLRU creates a new instance of the LRU cache with a list as a backend
+func NewListLRU[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return newCache(capacity, newListStorage[K, V], opts)
 }
 
-// NewListLRU creates a new instance of the LRU cache
-func NewListLRU(capacity int) LRU {
-	if capacity <= 0 {
-		capacity = 1
-	}
-
-	return &listLRU{
-		cache: make([]listLRUItem, 0, capacity),
+func newListStorage[K comparable, V any](capacity int) storage[K, V] {
+	return &listStorage[K, V]{
+		cache: make([]*cacheEntry[K, V], 0, capacity),
 	}
 }
 
-func (l *listLRU) Get(key string) (found bool, value interface{}) {
-	for i, item := range l.cache {
-		if item.key == key {
-			item.hits++
-			l.cache[i] = item
-			l.swap(i)
-
-			return true, item.value
-		}
+func (l *listStorage[K, V]) get(key K) *cacheEntry[K, V] {
+	if i := l.find(key); i >= 0 {
+		return l.cache[i]
 	}
-	return false, nil
+
+	return nil
 }
 
-func (l *listLRU) Set(key string, value interface{}) {
-	for i, item := range l.cache {
-		if item.key == key {
-			item.hits++
-			l.cache[i] = item
-			l.swap(i)
+func (l *listStorage[K, V]) add(entry *cacheEntry[K, V]) {
+	l.cache = append(l.cache, entry)
+}
 
-			return
-		}
+func (l *listStorage[K, V]) remove(key K) *cacheEntry[K, V] {
+	i := l.find(key)
+	if i < 0 {
+		return nil
 	}
 
-	if len(l.cache) == cap(l.cache) {
-		l.cache[len(l.cache)-1].hits = 1
-		l.cache[len(l.cache)-1].key = key
-		l.cache[len(l.cache)-1].value = value
-	} else {
-		l.cache = append(l.cache, listLRUItem{hits: 1, key: key, value: value})
-	}
+	entry := l.cache[i]
+	copy(l.cache[i:], l.cache[i+1:])
+	l.cache[len(l.cache)-1] = nil
+	l.cache = l.cache[:len(l.cache)-1]
+	return entry
 }
 
-func (l *listLRU) Size() int {
+func (l *listStorage[K, V]) len() int {
 	return len(l.cache)
 }
 
-func (l *listLRU) extractPopularityKeys() []string {
-	keys := make([]string, 0, len(l.cache))
-	for _, item := range l.cache {
-		keys = append(keys, item.key)
-	}
+func (l *listStorage[K, V]) backend() string {
+	return "list"
+}
 
-	return keys
+func (l *listStorage[K, V]) clear() {
+	clear(l.cache)
+	l.cache = l.cache[:0]
 }
 
-func (l *listLRU) swap(i int) {
-	for {
-		if i == 0 || l.cache[i].hits <= l.cache[i-1].hits {
+func (l *listStorage[K, V]) each(fn func(entry *cacheEntry[K, V]) bool) {
+	for _, entry := range l.cache {
+		if !fn(entry) {
 			return
 		}
-		prevItem := l.cache[i-1]
-		l.cache[i-1].hits = l.cache[i].hits
-		l.cache[i-1].key = l.cache[i].key
-		l.cache[i-1].value = l.cache[i].value
-
-		l.cache[i].hits = prevItem.hits
-		l.cache[i].key = prevItem.key
-		l.cache[i].value = prevItem.value
+	}
+}
 
-		i--
+func (l *listStorage[K, V]) find(key K) int {
+	for i := range l.cache {
+		if l.cache[i].key == key {
+			return i
+		}
 	}
+
+	return -1
 }
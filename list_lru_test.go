@@ -5,5 +5,69 @@ import (
 )
 
 func TestListLRUCache(t *testing.T) {
-	testLRUCache(t, NewListLRU)
+	testLRUCache(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_IntKeys(t *testing.T) {
+	testLRUCacheIntKeys(t, NewListLRU[int, []byte])
+}
+
+func TestListLRUCache_Delete(t *testing.T) {
+	testLRUCacheDelete(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Peek(t *testing.T) {
+	testLRUCachePeek(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Clear(t *testing.T) {
+	testLRUCacheClear(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Replace(t *testing.T) {
+	testLRUCacheReplace(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_LeastRecentlyUsed(t *testing.T) {
+	testLRUCacheLeastRecentlyUsed(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_CustomPolicy(t *testing.T) {
+	testLRUCacheCustomPolicy(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_TTL(t *testing.T) {
+	testLRUCacheTTL(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_ExpireAfterAccess(t *testing.T) {
+	testLRUCacheExpireAfterAccess(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_OnEvict(t *testing.T) {
+	testLRUCacheOnEvict(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_SetAndEvict(t *testing.T) {
+	testLRUCacheSetAndEvict(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Stats(t *testing.T) {
+	testLRUCacheStats(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_TopK(t *testing.T) {
+	testLRUCacheTopK(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Aging(t *testing.T) {
+	testLRUCacheAging(t, NewListLRU[string, string])
+}
+
+func TestListLRUCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewListLRU[int, int])
+}
+
+func TestListLRUCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewListLRU[int, int])
 }
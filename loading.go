@@ -0,0 +1,232 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadedAtSlack lets the load times of evicted keys pile up a bit before they are cleaned up
+const loadedAtSlack = 64
+
+// ErrLoaderPanicked is returned by GetOrLoad when the loader panics, the error also has the value of the panic
+var ErrLoaderPanicked = errors.New("lru: loader panicked")
+
+// Loader loads the value of the key which isn't in the cache
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type loadCall[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+	// refresh calls run in the background and aren't cancelled when the waiters give up
+	refresh bool
+}
+
+// LoadingCache is a cache which loads missing values on demand. Concurrent loads of the same key share a single call
+// of the loader
+type LoadingCache[K comparable, V any] struct {
+	LRU[K, V]
+
+	options options[K, V]
+
+	mu    sync.Mutex
+	calls map[K]*loadCall[V]
+	// loadedAt keeps the time of the last load of the keys, it's used only when refreshing is enabled.
+	// It's read without the lock on every hit
+	loadedAt sync.Map
+	// loadedAtSize is the number of keys in loadedAt
+	loadedAtSize atomic.Int64
+	// cleaningUp is set while cleanUpLoadedAt is running
+	cleaningUp atomic.Bool
+
+	loadSuccesses atomic.Uint64
+	loadFailures  atomic.Uint64
+	loadTime      atomic.Int64
+}
+
+// NewLoadingCache creates a loading cache on top of the given cache.
+// The cache has to be safe for concurrent use, e.g. created with NewSynchronized, NewConcurrent or NewShardedLRU.
+//
+// The loading cache takes WithTTL, WithRefreshAfterWrite and WithClock options, other options are ignored.
+// WithTTL sets the hard expiration of the loaded values: once it's passed callers wait for a new value
+func NewLoadingCache[K comparable, V any](lru LRU[K, V], opts ...Option[K, V]) *LoadingCache[K, V] {
+	return &LoadingCache[K, V]{
+		LRU:     lru,
+		options: newOptions(opts),
+		calls:   make(map[K]*loadCall[V]),
+	}
+}
+
+// GetOrLoad returns the value of the key from the cache or loads it with the loader and puts it into the cache.
+// Callers which ask for the key while it's being loaded wait for the same load. Errors of the loader are returned
+// to all of them and aren't cached. A panic of the loader is returned as an error which wraps ErrLoaderPanicked.
+//
+// The loader gets a context which keeps the values of ctx, but is cancelled only when all callers waiting for
+// the load have given up. GetOrLoad returns ctx.Err() when ctx is done before the value is loaded.
+//
+// With WithRefreshAfterWrite a value which is older than the refresh age is returned right away,
+// while a single background load replaces it
+func (l *LoadingCache[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	if found, value := l.Get(key); found {
+		if l.options.refreshAfterWrite > 0 {
+			l.refresh(ctx, key, loader)
+		}
+
+		return value, nil
+	}
+
+	l.mu.Lock()
+	call, ok := l.calls[key]
+	if !ok {
+		// the value could have been loaded while we were waiting for the lock. Peek doesn't count the second read
+		if found, value := l.Peek(key); found {
+			l.mu.Unlock()
+			return value, nil
+		}
+
+		var loadCtx context.Context
+		call = &loadCall[V]{done: make(chan struct{})}
+		loadCtx, call.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		l.calls[key] = call
+
+		go l.load(loadCtx, key, loader, call)
+	}
+	call.waiters++
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+
+	case <-ctx.Done():
+		l.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 && !call.refresh {
+			call.cancel()
+			if l.calls[key] == call {
+				delete(l.calls, key)
+			}
+		}
+		l.mu.Unlock()
+
+		var value V
+		return value, ctx.Err()
+	}
+}
+
+// Stats returns the statistics of the wrapped cache together with the statistics of the loads
+func (l *LoadingCache[K, V]) Stats() Stats {
+	return l.LRU.Stats().merge(Stats{
+		LoadSuccesses: l.loadSuccesses.Load(),
+		LoadFailures:  l.loadFailures.Load(),
+		LoadTime:      time.Duration(l.loadTime.Load()),
+	})
+}
+
+func (l *LoadingCache[K, V]) limit() int {
+	return capacityOf(l.LRU)
+}
+
+func (l *LoadingCache[K, V]) backend() string {
+	return backendOf(l.LRU)
+}
+
+// refresh starts a background load of the key if its value is older than the refresh age
+// and the key isn't being loaded already
+func (l *LoadingCache[K, V]) refresh(ctx context.Context, key K, loader Loader[K, V]) {
+	if !l.stale(key) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the key could have been refreshed while we were waiting for the lock
+	if _, loading := l.calls[key]; loading || !l.stale(key) {
+		return
+	}
+
+	var loadCtx context.Context
+	call := &loadCall[V]{done: make(chan struct{}), refresh: true}
+	loadCtx, call.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	l.calls[key] = call
+
+	go l.load(loadCtx, key, loader, call)
+}
+
+// stale reports whether the value of the key is older than the refresh age
+func (l *LoadingCache[K, V]) stale(key K) bool {
+	loadedAt, ok := l.loadedAt.Load(key)
+	return ok && l.options.clock.Now().Sub(loadedAt.(time.Time)) >= l.options.refreshAfterWrite
+}
+
+func (l *LoadingCache[K, V]) load(ctx context.Context, key K, loader Loader[K, V], call *loadCall[V]) {
+	defer call.cancel()
+
+	start := l.options.clock.Now()
+	value, err := callLoader(ctx, key, loader)
+	l.loadTime.Add(int64(l.options.clock.Now().Sub(start)))
+	switch {
+	case err != nil:
+		l.loadFailures.Add(1)
+	case l.options.ttl > 0:
+		l.loadSuccesses.Add(1)
+		l.SetWithTTL(key, value, l.options.ttl)
+	default:
+		l.loadSuccesses.Add(1)
+		l.Set(key, value)
+	}
+
+	l.mu.Lock()
+	call.value, call.err = value, err
+	if l.calls[key] == call {
+		delete(l.calls, key)
+	}
+
+	if err == nil && l.options.refreshAfterWrite > 0 {
+		if _, loaded := l.loadedAt.Swap(key, l.options.clock.Now()); !loaded {
+			l.loadedAtSize.Add(1)
+		}
+	}
+	l.mu.Unlock()
+
+	close(call.done)
+
+	if err == nil && l.options.refreshAfterWrite > 0 {
+		l.cleanUpLoadedAt()
+	}
+}
+
+// callLoader calls the loader and turns its panic into an error, so the callers waiting for the load are released
+func callLoader[K comparable, V any](ctx context.Context, key K, loader Loader[K, V]) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+	}()
+
+	return loader(ctx, key)
+}
+
+// cleanUpLoadedAt forgets the load times of the keys which aren't in the cache anymore. It's called without the lock,
+// because Contains may call the listener of the removed items, which may use the loading cache
+func (l *LoadingCache[K, V]) cleanUpLoadedAt() {
+	if l.loadedAtSize.Load() <= int64(2*l.Size()+loadedAtSlack) || !l.cleaningUp.CompareAndSwap(false, true) {
+		return
+	}
+	defer l.cleaningUp.Store(false)
+
+	l.loadedAt.Range(func(key, loadedAt any) bool {
+		// the key could have been loaded again after Contains, then its new load time stays
+		if !l.Contains(key.(K)) && l.loadedAt.CompareAndDelete(key, loadedAt) {
+			l.loadedAtSize.Add(-1)
+		}
+		return true
+	})
+}
@@ -0,0 +1,431 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/melan/go-lru/lrutest"
+)
+
+func TestLoadingCache_GetOrLoad(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)))
+
+	var calls int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "value " + key, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		gotValue, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+		assert.NoError(t, gotErr)
+		assert.Equal(t, "value a", gotValue)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.True(t, cache.Contains("a"))
+}
+
+func TestLoadingCache_Stats(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)), WithClock[string, string](clock))
+
+	loader := func(ctx context.Context, key string) (string, error) {
+		clock.Advance(time.Second)
+		if key == "broken" {
+			return "", errors.New("broken key")
+		}
+
+		return "value " + key, nil
+	}
+
+	for _, key := range []string{"a", "a", "b", "broken"} {
+		cache.GetOrLoad(context.Background(), key, loader)
+	}
+
+	gotStats := cache.Stats()
+	assert.Equal(t, uint64(1), gotStats.Hits)
+	assert.Equal(t, uint64(3), gotStats.Misses)
+	assert.Equal(t, uint64(2), gotStats.Insertions)
+	assert.Equal(t, uint64(2), gotStats.LoadSuccesses)
+	assert.Equal(t, uint64(1), gotStats.LoadFailures)
+	assert.Equal(t, 3*time.Second, gotStats.LoadTime)
+	assert.Equal(t, time.Second, gotStats.AverageLoadTime())
+}
+
+func TestLoadingCache_Coalescing(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)))
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value " + key, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	values := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			values[i], err = cache.GetOrLoad(context.Background(), "a", loader)
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	assert.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+
+		call, ok := cache.calls["a"]
+		return ok && call.waiters == callers
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, value := range values {
+		assert.Equal(t, "value a", value)
+	}
+}
+
+func TestLoadingCache_ErrorsAreNotCached(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)))
+
+	errLoad := errors.New("database is down")
+	var calls int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return "", errLoad
+		}
+
+		return "value " + key, nil
+	}
+
+	gotValue, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+	assert.True(t, errors.Is(gotErr, errLoad))
+	assert.Empty(t, gotValue)
+	assert.False(t, cache.Contains("a"))
+
+	gotValue, gotErr = cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "value a", gotValue)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestLoadingCache_Cancellation(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)))
+
+	t.Run("other callers still get the value", func(t *testing.T) {
+		release := make(chan struct{})
+		loader := func(ctx context.Context, key string) (string, error) {
+			select {
+			case <-release:
+				return "value " + key, nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		impatient := make(chan error)
+		go func() {
+			_, err := cache.GetOrLoad(ctx, "a", loader)
+			impatient <- err
+		}()
+
+		patient := make(chan string)
+		go func() {
+			value, err := cache.GetOrLoad(context.Background(), "a", loader)
+			assert.NoError(t, err)
+			patient <- value
+		}()
+
+		assert.Eventually(t, func() bool {
+			cache.mu.Lock()
+			defer cache.mu.Unlock()
+
+			call, ok := cache.calls["a"]
+			return ok && call.waiters == 2
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		assert.True(t, errors.Is(<-impatient, context.Canceled))
+
+		close(release)
+		assert.Equal(t, "value a", <-patient)
+	})
+
+	t.Run("loader is cancelled when nobody waits", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		loaderCancelled := make(chan struct{})
+		_, gotErr := cache.GetOrLoad(ctx, "b", func(ctx context.Context, key string) (string, error) {
+			<-ctx.Done()
+			close(loaderCancelled)
+			return "", ctx.Err()
+		})
+		assert.True(t, errors.Is(gotErr, context.DeadlineExceeded))
+
+		select {
+		case <-loaderCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("loader wasn't cancelled")
+		}
+		assert.False(t, cache.Contains("b"))
+	})
+}
+
+func TestLoadingCache_RefreshAfterWrite(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewLoadingCache(
+		NewSynchronized(NewMapLRU[string, string](10, WithClock[string, string](clock))),
+		WithRefreshAfterWrite[string, string](time.Minute),
+		WithTTL[string, string](5*time.Minute),
+		WithClock[string, string](clock),
+	)
+
+	var version int32
+	var calls int32
+	release := make(chan struct{}, 10)
+	loader := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&version) > 0 {
+			<-release
+		}
+		return fmt.Sprintf("%s v%d", key, atomic.AddInt32(&version, 1)), nil
+	}
+
+	gotValue, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "a v1", gotValue)
+
+	clock.Advance(59 * time.Second)
+	gotValue, _ = cache.GetOrLoad(context.Background(), "a", loader)
+	assert.Equal(t, "a v1", gotValue)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "fresh value shouldn't be refreshed")
+
+	clock.Advance(time.Second)
+	for i := 0; i < 5; i++ {
+		gotValue, gotErr = cache.GetOrLoad(context.Background(), "a", loader)
+		assert.NoError(t, gotErr)
+		assert.Equal(t, "a v1", gotValue, "stale value should be returned while it's refreshed")
+	}
+
+	release <- struct{}{}
+	assert.Eventually(t, func() bool {
+		_, value := cache.Peek("a")
+		return value == "a v2"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "stale value should be refreshed once")
+
+	clock.Advance(5 * time.Minute)
+	release <- struct{}{}
+	gotValue, gotErr = cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "a v3", gotValue, "expired value should be loaded synchronously")
+}
+
+func TestLoadingCache_RefreshErrorKeepsStaleValue(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewLoadingCache(
+		NewSynchronized(NewMapLRU[string, string](10, WithClock[string, string](clock))),
+		WithRefreshAfterWrite[string, string](time.Minute),
+		WithClock[string, string](clock),
+	)
+
+	errLoad := errors.New("database is down")
+	var calls int32
+	loader := func(ctx context.Context, key string) (string, error) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			return "", errLoad
+		}
+		return "value " + key, nil
+	}
+
+	_, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+
+	clock.Advance(time.Minute)
+	gotValue, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "value a", gotValue)
+
+	assert.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+
+		return atomic.LoadInt32(&calls) == 2 && len(cache.calls) == 0
+	}, time.Second, time.Millisecond)
+
+	gotValue, gotErr = cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "value a", gotValue)
+}
+
+func TestLoadingCache_FreshHitsDontLock(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)), WithRefreshAfterWrite[string, string](time.Hour))
+	loader := func(ctx context.Context, key string) (string, error) {
+		return "value " + key, nil
+	}
+
+	_, gotErr := cache.GetOrLoad(context.Background(), "a", loader)
+	assert.NoError(t, gotErr)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	done := make(chan string)
+	go func() {
+		value, _ := cache.GetOrLoad(context.Background(), "a", loader)
+		done <- value
+	}()
+
+	select {
+	case gotValue := <-done:
+		assert.Equal(t, "value a", gotValue)
+	case <-time.After(time.Second):
+		t.Fatal("a hit of a fresh value waits for the lock of the loads")
+	}
+}
+
+func TestLoadingCache_LoaderPanic(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := NewLoadingCache(
+		NewSynchronized(NewMapLRU[string, string](10)),
+		WithRefreshAfterWrite[string, string](time.Minute),
+		WithClock[string, string](clock),
+	)
+
+	release := make(chan struct{})
+	panicking := func(ctx context.Context, key string) (string, error) {
+		<-release
+		panic("broken loader")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.GetOrLoad(context.Background(), "a", panicking)
+			errs <- err
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return cache.calls["a"] != nil && cache.calls["a"].waiters == 3
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.True(t, errors.Is(err, ErrLoaderPanicked), err)
+		assert.Contains(t, err.Error(), "broken loader")
+	}
+	assert.Equal(t, uint64(1), cache.Stats().LoadFailures)
+	assert.Empty(t, cache.calls)
+
+	// a panic of a background refresh keeps the stale value
+	gotValue, gotErr := cache.GetOrLoad(context.Background(), "a", func(ctx context.Context, key string) (string, error) {
+		return "value " + key, nil
+	})
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "value a", gotValue)
+
+	clock.Advance(time.Minute)
+	gotValue, gotErr = cache.GetOrLoad(context.Background(), "a", panicking)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "value a", gotValue)
+	assert.Eventually(t, func() bool {
+		return cache.Stats().LoadFailures == 2
+	}, time.Second, time.Millisecond)
+
+	_, gotValue = cache.Peek("a")
+	assert.Equal(t, "value a", gotValue)
+}
+
+func TestLoadingCache_cleanUpLoadedAt(t *testing.T) {
+	cache := NewLoadingCache(NewSynchronized(NewMapLRU[int, int](10)), WithRefreshAfterWrite[int, int](time.Minute))
+
+	loader := func(ctx context.Context, key int) (int, error) {
+		return key, nil
+	}
+
+	for i := 0; i < 1000; i++ {
+		_, gotErr := cache.GetOrLoad(context.Background(), i, loader)
+		assert.NoError(t, gotErr)
+	}
+
+	assert.LessOrEqual(t, cache.loadedAtSize.Load(), int64(2*cache.Size()+loadedAtSlack))
+
+	size := 0
+	cache.loadedAt.Range(func(any, any) bool {
+		size++
+		return true
+	})
+	assert.Equal(t, cache.loadedAtSize.Load(), int64(size))
+}
+
+// TestLoadingCache_cleanUpLoadedAtListener checks that the listener called by the clean up may load values
+func TestLoadingCache_cleanUpLoadedAtListener(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	loader := func(ctx context.Context, key int) (int, error) {
+		return key, nil
+	}
+
+	var cache *LoadingCache[int, int]
+	cache = NewLoadingCache(
+		NewSynchronized(NewMapLRU[int, int](1000,
+			WithClock[int, int](clock),
+			WithOnEvict(func(key int, _ int, reason RemovalReason) {
+				if reason == ReasonExpired && key < 1000 {
+					cache.GetOrLoad(context.Background(), key+1000, loader)
+				}
+			}),
+		)),
+		WithRefreshAfterWrite[int, int](time.Minute),
+		WithTTL[int, int](time.Minute),
+		WithClock[int, int](clock),
+	)
+
+	for i := 0; i < 100; i++ {
+		_, gotErr := cache.GetOrLoad(context.Background(), i, loader)
+		assert.NoError(t, gotErr)
+	}
+
+	// the load times of the deleted keys pile up, so the next load cleans them up and finds the expired keys
+	for i := 0; i < 90; i++ {
+		cache.Delete(i)
+	}
+	clock.Advance(2 * time.Minute)
+
+	_, gotErr := cache.GetOrLoad(context.Background(), 100, loader)
+	assert.NoError(t, gotErr)
+	assert.Eventually(t, func() bool {
+		for i := 1090; i < 1100; i++ {
+			if !cache.Contains(i) {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
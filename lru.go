@@ -1,13 +1,55 @@
 package lru
 
+import "time"
+
 // LRU is an interface for different implementations of the LRU cache
-type LRU interface {
-	lruPopularityExtractor
-	Get(key string) (bool, interface{})
-	Set(key string, value interface{})
+type LRU[K comparable, V any] interface {
+	lruPopularityExtractor[K]
+	Get(key K) (bool, V)
+	// Set adds the key to the cache or replaces the value of the existing key.
+	// Keys which aren't equal to themselves, like NaN, are ignored
+	Set(key K, value V)
+	// SetAndEvict works like Set and returns the item which was evicted to make room for the new one.
+	// Replaced values of the existing key aren't reported
+	SetAndEvict(key K, value V) (evictedKey K, evictedValue V, evicted bool)
+	// SetWithTTL works like Set, but the item expires after ttl. Items with not positive ttl never expire
+	SetWithTTL(key K, value V, ttl time.Duration)
+	// Replace works like Set and returns the previous value of the key if it was in the cache
+	Replace(key K, value V) (bool, V)
+	// Size returns the number of items in the cache, it may include expired items which weren't removed yet
 	Size() int
+	// Delete removes the key from the cache and reports whether it was there
+	Delete(key K) bool
+	// Peek returns the value of the key without changing its popularity
+	Peek(key K) (bool, V)
+	// Contains reports whether the key is in the cache without changing its popularity
+	Contains(key K) bool
+	// Clear removes all items from the cache
+	Clear()
+	// TopK returns up to n most popular keys with their hits, the most popular key goes first.
+	// With the LeastRecentlyUsed ordering the keys go from the most recently used one.
+	// It returns nil if the policy of the cache doesn't rank the keys
+	TopK(n int) []KeyHits[K]
+	// Coldest works like TopK, but returns the least popular keys, starting from the next victim of the eviction
+	Coldest(n int) []KeyHits[K]
+	// Stats returns a snapshot of the statistics of the cache
+	Stats() Stats
+	// Close stops the background goroutines of the cache, if there are any
+	Close()
+}
+
+// KeyHits is a key of the cache with the number of its accesses, the insertion of the key counts as the first one
+type KeyHits[K comparable] struct {
+	Key  K
+	Hits int
+}
+
+// popularityRanker is implemented by the policies which keep the keys ordered by their popularity
+type popularityRanker[K comparable] interface {
+	topK(n int) []KeyHits[K]
+	coldest(n int) []KeyHits[K]
 }
 
-type lruPopularityExtractor interface {
-	extractPopularityKeys() []string
+type lruPopularityExtractor[K comparable] interface {
+	extractPopularityKeys() []K
 }
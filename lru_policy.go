@@ -0,0 +1,59 @@
+package lru
+
+// lruPolicy moves a key to the head on every access, so the tail is always the least recently used key
+type lruPolicy[K comparable] struct {
+	nodes map[K]*popularityNode[K]
+	list  popularityList[K]
+}
+
+// NewLRUPolicy creates a policy which evicts the least recently used key
+func NewLRUPolicy[K comparable]() Policy[K] {
+	return &lruPolicy[K]{nodes: make(map[K]*popularityNode[K])}
+}
+
+func (p *lruPolicy[K]) OnInsert(key K) {
+	if _, ok := p.nodes[key]; ok {
+		p.OnAccess(key)
+		return
+	}
+
+	p.list.age()
+	node := &popularityNode[K]{key: key, hits: 1}
+	p.nodes[key] = node
+	p.list.pushFront(node)
+}
+
+func (p *lruPolicy[K]) OnAccess(key K) {
+	p.list.age()
+	if node, ok := p.nodes[key]; ok {
+		node.hits++
+		p.list.moveToFront(node)
+	}
+}
+
+func (p *lruPolicy[K]) OnRemove(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.list.unlink(node)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *lruPolicy[K]) Victim() (key K, found bool) {
+	if p.list.popularityTail == nil {
+		return key, false
+	}
+
+	return p.list.popularityTail.key, true
+}
+
+func (p *lruPolicy[K]) topK(n int) []KeyHits[K] {
+	return p.list.topK(n)
+}
+
+func (p *lruPolicy[K]) coldest(n int) []KeyHits[K] {
+	return p.list.coldest(n)
+}
+
+func (p *lruPolicy[K]) extractPopularityKeys() []K {
+	return p.list.extractPopularityKeys()
+}
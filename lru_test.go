@@ -2,19 +2,17 @@ package lru
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-)
 
-func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
-	type cacheItem struct {
-		key      string
-		value    interface{}
-		expected bool
-	}
+	"github.com/melan/go-lru/lrutest"
+)
 
+func testLRUCache(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
 	tests := []struct {
 		name              string
 		capacity          int
@@ -22,6 +20,12 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 		wantSize          int
 		wantItemsPriority []string
 		wantItems         []string
+		// wantRecentItems is the expected order of items with the LeastRecentlyUsed ordering
+		wantRecentItems []string
+		// wantAdaptiveItems is the expected order of items with the AdaptiveReplacement ordering
+		wantAdaptiveItems []string
+		// wantTwoQueueItems is the expected order of items with the TwoQueue ordering
+		wantTwoQueueItems []string
 	}{
 		{
 			name:              "Single item",
@@ -30,6 +34,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: []string{"a"},
 			wantItems:         []string{"a"},
 			wantSize:          1,
+			wantRecentItems:   []string{"a"},
+			wantAdaptiveItems: []string{"a"},
+			wantTwoQueueItems: []string{"a"},
 		},
 		{
 			name:              "Zero capacity",
@@ -38,6 +45,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: []string{"c"},
 			wantItems:         []string{"c"},
 			wantSize:          1,
+			wantRecentItems:   []string{"c"},
+			wantAdaptiveItems: []string{"c"},
+			wantTwoQueueItems: []string{"c"},
 		},
 		{
 			name:              "Exact cache capacity",
@@ -46,6 +56,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: []string{"a", "b"},
 			wantItems:         []string{"a", "b"},
 			wantSize:          2,
+			wantRecentItems:   []string{"b", "a"},
+			wantAdaptiveItems: []string{"b", "a"},
+			wantTwoQueueItems: []string{"b", "a"},
 		},
 		{
 			name:              "Duplicates",
@@ -54,6 +67,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: []string{"a", "b"},
 			wantItems:         []string{"a", "b"},
 			wantSize:          2,
+			wantRecentItems:   []string{"a", "b"},
+			wantAdaptiveItems: []string{"a", "b"},
+			wantTwoQueueItems: []string{"b", "a"},
 		},
 		{
 			name:              "Evictions",
@@ -62,6 +78,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: strings.Split("ac", ""),
 			wantItems:         strings.Split("ac", ""),
 			wantSize:          2,
+			wantRecentItems:   strings.Split("ac", ""),
+			wantAdaptiveItems: strings.Split("ac", ""),
+			wantTwoQueueItems: strings.Split("ac", ""),
 		},
 		{
 			name:              "Duplicates, Swaps and Evictions",
@@ -70,6 +89,9 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			wantItemsPriority: strings.Split("cedz", ""),
 			wantItems:         strings.Split("cedz", ""),
 			wantSize:          4,
+			wantRecentItems:   strings.Split("cdeb", ""),
+			wantAdaptiveItems: strings.Split("cdeb", ""),
+			wantTwoQueueItems: strings.Split("edzc", ""),
 		},
 		{
 			name:              "evictoins with duplicates",
@@ -77,6 +99,20 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 			capacity:          10,
 			wantSize:          10,
 			wantItemsPriority: strings.Split("hfalsjkcmd", ""),
+			wantRecentItems:   strings.Split("fdhskjavbu", ""),
+			wantAdaptiveItems: strings.Split("fdhskjavbu", ""),
+			wantTwoQueueItems: strings.Split("dhkvbuwfsj", ""),
+		},
+		{
+			name:              "Once popular item",
+			capacity:          3,
+			items:             strings.Split("aaaaabcd", ""),
+			wantItemsPriority: strings.Split("abd", ""),
+			wantItems:         strings.Split("abd", ""),
+			wantRecentItems:   strings.Split("dcb", ""),
+			wantSize:          3,
+			wantAdaptiveItems: strings.Split("adc", ""),
+			wantTwoQueueItems: strings.Split("dcb", ""),
 		},
 	}
 
@@ -101,5 +137,689 @@ func testLRUCache(t *testing.T, newLRU func(capacity int) LRU) {
 				assert.Equal(t, testValue, gotValue, fmt.Sprintf("Value of %q mismatches", key))
 			}
 		})
+
+		for _, ordering := range []struct {
+			name      string
+			ordering  Ordering
+			wantItems []string
+		}{
+			{"adaptive replacement", AdaptiveReplacement, tt.wantAdaptiveItems},
+			{"two queue", TwoQueue, tt.wantTwoQueueItems},
+		} {
+			t.Run(tt.name+", "+ordering.name, func(t *testing.T) {
+				cache := newLRU(tt.capacity, WithOrdering[string, string](ordering.ordering))
+				for _, key := range tt.items {
+					cache.Set(key, testValue)
+				}
+
+				assert.Equal(t, tt.wantSize, cache.Size())
+				assert.Equal(t, ordering.wantItems, cache.extractPopularityKeys(), "Items don't match")
+
+				for _, key := range ordering.wantItems {
+					gotFound, gotValue := cache.Get(key)
+
+					assert.True(t, gotFound, fmt.Sprintf("Item %q should be found", key))
+					assert.Equal(t, testValue, gotValue, fmt.Sprintf("Value of %q mismatches", key))
+				}
+			})
+		}
+
+		t.Run(tt.name+", least recently used", func(t *testing.T) {
+			cache := newLRU(tt.capacity, WithOrdering[string, string](LeastRecentlyUsed))
+			for _, key := range tt.items {
+				cache.Set(key, testValue)
+			}
+
+			assert.Equal(t, tt.wantSize, cache.Size())
+
+			gotRecencyList := cache.extractPopularityKeys()
+			assert.Equal(t, tt.wantRecentItems, gotRecencyList, "Recency list doesn't match")
+
+			for _, key := range tt.wantRecentItems {
+				gotFound, gotValue := cache.Get(key)
+
+				assert.True(t, gotFound, fmt.Sprintf("Item %q should be found", key))
+				assert.Equal(t, testValue, gotValue, fmt.Sprintf("Value of %q mismatches", key))
+			}
+
+			// every get moves the item to the front, so the order is reversed now
+			gotRecencyList = cache.extractPopularityKeys()
+			for i, key := range tt.wantRecentItems {
+				assert.Equal(t, key, gotRecencyList[len(gotRecencyList)-1-i])
+			}
+		})
+	}
+}
+
+func testLRUCacheLeastRecentlyUsed(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	cache := newLRU(3, WithOrdering[string, string](LeastRecentlyUsed))
+	for _, key := range strings.Split("abc", "") {
+		cache.Set(key, "value "+key)
+	}
+
+	cache.Get("a")
+	cache.Peek("b")
+	cache.Set("d", "value d")
+
+	assert.False(t, cache.Contains("b"), "least recently used item should be evicted")
+	assert.Equal(t, []string{"d", "a", "c"}, cache.extractPopularityKeys())
+
+	cache.Replace("c", "new value c")
+	cache.Delete("a")
+	cache.Set("e", "value e")
+	cache.Set("f", "value f")
+
+	assert.Equal(t, []string{"f", "e", "c"}, cache.extractPopularityKeys())
+}
+
+func testLRUCacheIntKeys(t *testing.T, newLRU func(capacity int, opts ...Option[int, []byte]) LRU[int, []byte]) {
+	cache := newLRU(3)
+	for i := 1; i <= 4; i++ {
+		cache.Set(i, []byte(strconv.Itoa(i)))
+	}
+
+	assert.Equal(t, 3, cache.Size())
+	assert.Equal(t, []int{1, 2, 4}, cache.extractPopularityKeys())
+
+	gotFound, gotValue := cache.Get(4)
+	assert.True(t, gotFound)
+	assert.Equal(t, []byte("4"), gotValue)
+
+	gotFound, gotValue = cache.Get(3)
+	assert.False(t, gotFound)
+	assert.Nil(t, gotValue)
+}
+
+func testLRUCacheDelete(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	tests := []struct {
+		name              string
+		capacity          int
+		items             []string
+		deleteItems       []string
+		wantDeleted       []bool
+		wantSize          int
+		wantItemsPriority []string
+	}{
+		{
+			name:              "Delete missing key",
+			capacity:          3,
+			items:             strings.Split("ab", ""),
+			deleteItems:       []string{"c"},
+			wantDeleted:       []bool{false},
+			wantSize:          2,
+			wantItemsPriority: strings.Split("ab", ""),
+		},
+		{
+			name:              "Delete the only key",
+			capacity:          3,
+			items:             []string{"a"},
+			deleteItems:       []string{"a", "a"},
+			wantDeleted:       []bool{true, false},
+			wantSize:          0,
+			wantItemsPriority: []string{},
+		},
+		{
+			name:              "Delete the least popular key",
+			capacity:          3,
+			items:             strings.Split("abcaab", ""),
+			deleteItems:       []string{"c"},
+			wantDeleted:       []bool{true},
+			wantSize:          2,
+			wantItemsPriority: strings.Split("ab", ""),
+		},
+		{
+			name:              "Delete the most popular key",
+			capacity:          3,
+			items:             strings.Split("abcaab", ""),
+			deleteItems:       []string{"a"},
+			wantDeleted:       []bool{true},
+			wantSize:          2,
+			wantItemsPriority: strings.Split("bc", ""),
+		},
+		{
+			name:              "Delete a key in the middle",
+			capacity:          5,
+			items:             strings.Split("edcbaddeee", ""),
+			deleteItems:       []string{"d", "b"},
+			wantDeleted:       []bool{true, true},
+			wantSize:          3,
+			wantItemsPriority: strings.Split("eca", ""),
+		},
+	}
+
+	testValue := "some value"
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newLRU(tt.capacity)
+			for _, key := range tt.items {
+				cache.Set(key, testValue)
+			}
+
+			for i, key := range tt.deleteItems {
+				assert.Equal(t, tt.wantDeleted[i], cache.Delete(key), fmt.Sprintf("Deletion of %q mismatches", key))
+				assert.False(t, cache.Contains(key), fmt.Sprintf("Item %q shouldn't be found", key))
+			}
+
+			assert.Equal(t, tt.wantSize, cache.Size())
+			assert.Equal(t, tt.wantItemsPriority, cache.extractPopularityKeys(), "Popularity list doesn't match")
+
+			// the cache must keep working after deletions
+			cache.Set("z", testValue)
+			assert.True(t, cache.Contains("z"))
+		})
+	}
+}
+
+func testLRUCachePeek(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	cache := newLRU(2)
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+
+	for i := 0; i < 3; i++ {
+		gotFound, gotValue := cache.Peek("b")
+		assert.True(t, gotFound)
+		assert.Equal(t, "value b", gotValue)
+		assert.True(t, cache.Contains("b"))
+	}
+
+	gotFound, gotValue := cache.Peek("c")
+	assert.False(t, gotFound)
+	assert.Empty(t, gotValue)
+	assert.False(t, cache.Contains("c"))
+
+	// peeks don't make "b" more popular, so it's still the one to go
+	assert.Equal(t, []string{"a", "b"}, cache.extractPopularityKeys())
+	cache.Set("c", "value c")
+	assert.False(t, cache.Contains("b"))
+}
+
+func testLRUCacheClear(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	cache := newLRU(3)
+	for _, key := range strings.Split("abcab", "") {
+		cache.Set(key, "some value")
+	}
+
+	cache.Clear()
+
+	assert.Equal(t, 0, cache.Size())
+	assert.Empty(t, cache.extractPopularityKeys())
+	for _, key := range strings.Split("abc", "") {
+		assert.False(t, cache.Contains(key), fmt.Sprintf("Item %q shouldn't be found", key))
+	}
+
+	for _, key := range strings.Split("dcd", "") {
+		cache.Set(key, "some value")
+	}
+	assert.Equal(t, 2, cache.Size())
+	assert.Equal(t, []string{"d", "c"}, cache.extractPopularityKeys())
+}
+
+func testLRUCacheReplace(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	t.Run("Set replaces the value", func(t *testing.T) {
+		cache := newLRU(2)
+		cache.Set("a", "old value")
+		cache.Set("a", "new value")
+
+		gotFound, gotValue := cache.Get("a")
+		assert.True(t, gotFound)
+		assert.Equal(t, "new value", gotValue)
+	})
+
+	t.Run("Set keeps the existing value", func(t *testing.T) {
+		cache := newLRU(2, KeepExistingValues[string, string]())
+		cache.Set("a", "old value")
+		cache.Set("a", "new value")
+
+		gotFound, gotValue := cache.Get("a")
+		assert.True(t, gotFound)
+		assert.Equal(t, "old value", gotValue)
+	})
+
+	for _, keepExisting := range []bool{false, true} {
+		var opts []Option[string, string]
+		if keepExisting {
+			opts = append(opts, KeepExistingValues[string, string]())
+		}
+
+		t.Run(fmt.Sprintf("Replace returns the previous value, keep existing values: %t", keepExisting), func(t *testing.T) {
+			cache := newLRU(2, opts...)
+
+			gotFound, gotPrevious := cache.Replace("a", "first value")
+			assert.False(t, gotFound)
+			assert.Empty(t, gotPrevious)
+
+			gotFound, gotPrevious = cache.Replace("a", "second value")
+			assert.True(t, gotFound)
+			assert.Equal(t, "first value", gotPrevious)
+
+			gotFound, gotValue := cache.Peek("a")
+			assert.True(t, gotFound)
+			assert.Equal(t, "second value", gotValue)
+		})
+	}
+
+	t.Run("Replacing counts as a hit", func(t *testing.T) {
+		cache := newLRU(2)
+		cache.Set("a", "value a")
+		cache.Set("b", "value b")
+		cache.Replace("b", "new value b")
+		cache.Set("c", "value c")
+
+		assert.Equal(t, []string{"b", "c"}, cache.extractPopularityKeys())
+	})
+}
+
+// fifoPolicy evicts the keys in the order they were added, accesses don't change anything
+type fifoPolicy[K comparable] struct {
+	keys []K
+}
+
+func (p *fifoPolicy[K]) OnInsert(key K) {
+	p.keys = append(p.keys, key)
+}
+
+func (p *fifoPolicy[K]) OnAccess(K) {}
+
+func (p *fifoPolicy[K]) OnRemove(key K) {
+	for i := range p.keys {
+		if p.keys[i] == key {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
 	}
 }
+
+func (p *fifoPolicy[K]) Victim() (key K, found bool) {
+	if len(p.keys) == 0 {
+		return key, false
+	}
+
+	return p.keys[0], true
+}
+
+func testLRUCacheCustomPolicy(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	policy := &fifoPolicy[string]{}
+	cache := newLRU(3, WithPolicy[string, string](policy))
+	for _, key := range strings.Split("abcaaab", "") {
+		cache.Set(key, "value "+key)
+	}
+
+	cache.Set("d", "value d")
+	assert.False(t, cache.Contains("a"), "the oldest item should be evicted")
+	assert.Equal(t, []string{"b", "c", "d"}, policy.keys)
+
+	cache.Delete("c")
+	cache.Set("e", "value e")
+	cache.Set("f", "value f")
+	assert.Equal(t, []string{"d", "e", "f"}, policy.keys)
+	assert.Equal(t, 3, cache.Size())
+
+	cache.Clear()
+	assert.Empty(t, policy.keys)
+	assert.Nil(t, cache.extractPopularityKeys(), "policy doesn't expose the popularity of the keys")
+}
+
+func testLRUCacheTTL(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	t.Run("Expired items are misses", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithClock[string, string](clock))
+
+		cache.SetWithTTL("a", "value a", time.Minute)
+		cache.SetWithTTL("b", "value b", 0)
+		cache.Set("c", "value c")
+
+		clock.Advance(59 * time.Second)
+		gotFound, gotValue := cache.Get("a")
+		assert.True(t, gotFound)
+		assert.Equal(t, "value a", gotValue)
+
+		clock.Advance(time.Second)
+		gotFound, gotValue = cache.Get("a")
+		assert.False(t, gotFound)
+		assert.Empty(t, gotValue)
+		assert.Equal(t, 2, cache.Size(), "expired item should be removed")
+
+		clock.Advance(24 * time.Hour)
+		assert.True(t, cache.Contains("b"))
+		assert.True(t, cache.Contains("c"))
+	})
+
+	t.Run("Default TTL", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithTTL[string, string](time.Minute), WithClock[string, string](clock))
+
+		cache.Set("a", "value a")
+		cache.SetWithTTL("b", "value b", time.Hour)
+		cache.SetWithTTL("c", "value c", 0)
+
+		clock.Advance(time.Minute)
+		assert.False(t, cache.Contains("a"))
+		assert.True(t, cache.Contains("b"))
+
+		gotFound, _ := cache.Peek("a")
+		assert.False(t, gotFound)
+
+		clock.Advance(time.Hour)
+		assert.False(t, cache.Contains("b"))
+		assert.True(t, cache.Contains("c"))
+		assert.Equal(t, []string{"c"}, cache.extractPopularityKeys())
+	})
+
+	t.Run("Setting a value resets the expiration", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithTTL[string, string](time.Minute), WithClock[string, string](clock))
+
+		cache.Set("a", "value a")
+		clock.Advance(30 * time.Second)
+		cache.Set("a", "new value a")
+		clock.Advance(45 * time.Second)
+		assert.True(t, cache.Contains("a"))
+
+		gotFound, gotPrevious := cache.Replace("a", "newer value a")
+		assert.True(t, gotFound)
+		assert.Equal(t, "new value a", gotPrevious)
+
+		clock.Advance(45 * time.Second)
+		assert.True(t, cache.Contains("a"))
+
+		clock.Advance(15 * time.Second)
+		gotFound, gotPrevious = cache.Replace("a", "value after expiration")
+		assert.False(t, gotFound, "expired item can't be replaced")
+		assert.Empty(t, gotPrevious)
+	})
+
+	t.Run("Expired items are evicted first", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithClock[string, string](clock))
+
+		cache.SetWithTTL("a", "value a", time.Minute)
+		cache.Set("b", "value b")
+		cache.SetWithTTL("c", "value c", time.Hour)
+		for i := 0; i < 5; i++ {
+			cache.Get("a")
+		}
+
+		clock.Advance(time.Minute)
+		cache.Set("d", "value d")
+
+		assert.Equal(t, 3, cache.Size())
+		assert.Equal(t, []string{"b", "c", "d"}, cache.extractPopularityKeys())
+	})
+}
+
+func testLRUCacheExpireAfterAccess(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	t.Run("Accesses extend the life of items", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithExpireAfterAccess[string, string](10*time.Minute), WithClock[string, string](clock))
+
+		cache.Set("a", "value a")
+		cache.Set("b", "value b")
+		for i := 0; i < 3; i++ {
+			clock.Advance(9 * time.Minute)
+			gotFound, gotValue := cache.Get("a")
+			assert.True(t, gotFound)
+			assert.Equal(t, "value a", gotValue)
+		}
+
+		assert.False(t, cache.Contains("b"), "idle item should expire")
+
+		clock.Advance(9 * time.Minute)
+		cache.Set("a", "new value a")
+		clock.Advance(9 * time.Minute)
+		assert.True(t, cache.Contains("a"))
+
+		clock.Advance(time.Minute)
+		assert.False(t, cache.Contains("a"), "Contains doesn't extend the life of items")
+		assert.Equal(t, 0, cache.Size())
+	})
+
+	t.Run("Idle timeout doesn't extend TTL", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(3, WithTTL[string, string](15*time.Minute), WithExpireAfterAccess[string, string](10*time.Minute), WithClock[string, string](clock))
+
+		cache.Set("a", "value a")
+		cache.SetWithTTL("b", "value b", 0)
+		for i := 0; i < 2; i++ {
+			clock.Advance(7 * time.Minute)
+			gotFoundA, _ := cache.Get("a")
+			gotFoundB, _ := cache.Get("b")
+			assert.True(t, gotFoundA)
+			assert.True(t, gotFoundB)
+		}
+
+		clock.Advance(time.Minute)
+		assert.False(t, cache.Contains("a"))
+		assert.True(t, cache.Contains("b"))
+	})
+
+	t.Run("Idle items are evicted first", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(2, WithExpireAfterAccess[string, string](10*time.Minute), WithClock[string, string](clock))
+
+		cache.Set("a", "value a")
+		cache.Set("b", "value b")
+		for i := 0; i < 3; i++ {
+			cache.Get("a")
+		}
+
+		clock.Advance(5 * time.Minute)
+		cache.Get("b")
+
+		clock.Advance(5 * time.Minute)
+		cache.Set("c", "value c")
+
+		assert.Equal(t, []string{"b", "c"}, cache.extractPopularityKeys())
+		assert.False(t, cache.Contains("a"))
+	})
+}
+
+type removedItem struct {
+	key    string
+	value  string
+	reason RemovalReason
+}
+
+func testLRUCacheOnEvict(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	clock := lrutest.NewFakeClock(time.Now())
+	var removed []removedItem
+	cache := newLRU(2, WithClock[string, string](clock), WithOnEvict(func(key string, value string, reason RemovalReason) {
+		removed = append(removed, removedItem{key: key, value: value, reason: reason})
+	}))
+
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+	cache.Get("a")
+	cache.Set("c", "value c")
+	assert.Equal(t, []removedItem{{"b", "value b", ReasonCapacity}}, removed)
+
+	removed = nil
+	cache.Set("a", "new value a")
+	cache.Replace("a", "newer value a")
+	cache.Replace("b", "value b")
+	assert.Equal(t, []removedItem{
+		{"a", "value a", ReasonReplaced},
+		{"a", "new value a", ReasonReplaced},
+		{"c", "value c", ReasonCapacity},
+	}, removed)
+
+	removed = nil
+	assert.True(t, cache.Delete("a"))
+	assert.False(t, cache.Delete("a"))
+	assert.Equal(t, []removedItem{{"a", "newer value a", ReasonDeleted}}, removed)
+
+	removed = nil
+	cache.SetWithTTL("d", "value d", time.Minute)
+	clock.Advance(time.Minute)
+	assert.False(t, cache.Contains("d"))
+	assert.Equal(t, []removedItem{{"d", "value d", ReasonExpired}}, removed)
+
+	removed = nil
+	cache.Clear()
+	assert.Equal(t, []removedItem{{"b", "value b", ReasonCleared}}, removed)
+}
+
+func testLRUCacheSetAndEvict(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	t.Run("Evicted items are returned", func(t *testing.T) {
+		cache := newLRU(2)
+
+		for _, key := range []string{"a", "b"} {
+			gotKey, gotValue, gotEvicted := cache.SetAndEvict(key, "value "+key)
+			assert.False(t, gotEvicted)
+			assert.Empty(t, gotKey)
+			assert.Empty(t, gotValue)
+		}
+
+		cache.Get("a")
+		gotKey, gotValue, gotEvicted := cache.SetAndEvict("c", "value c")
+		assert.True(t, gotEvicted)
+		assert.Equal(t, "b", gotKey)
+		assert.Equal(t, "value b", gotValue)
+
+		gotKey, gotValue, gotEvicted = cache.SetAndEvict("a", "new value a")
+		assert.False(t, gotEvicted, "replaced values aren't evicted items")
+		assert.Empty(t, gotKey)
+		assert.Empty(t, gotValue)
+
+		gotFound, gotValue := cache.Get("a")
+		assert.True(t, gotFound)
+		assert.Equal(t, "new value a", gotValue)
+		assert.Equal(t, 2, cache.Size())
+	})
+
+	t.Run("Expired items are evicted first", func(t *testing.T) {
+		clock := lrutest.NewFakeClock(time.Now())
+		cache := newLRU(2, WithClock[string, string](clock), WithOrdering[string, string](LeastRecentlyUsed))
+
+		cache.Set("a", "value a")
+		cache.SetWithTTL("b", "value b", time.Minute)
+		clock.Advance(time.Minute)
+
+		gotKey, gotValue, gotEvicted := cache.SetAndEvict("c", "value c")
+		assert.True(t, gotEvicted)
+		assert.Equal(t, "b", gotKey)
+		assert.Equal(t, "value b", gotValue)
+	})
+
+	t.Run("Existing values are kept", func(t *testing.T) {
+		cache := newLRU(1, KeepExistingValues[string, string]())
+
+		cache.Set("a", "value a")
+		_, _, gotEvicted := cache.SetAndEvict("a", "new value a")
+		assert.False(t, gotEvicted)
+
+		_, gotValue := cache.Get("a")
+		assert.Equal(t, "value a", gotValue)
+	})
+}
+
+func testLRUCacheStats(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	clock := lrutest.NewFakeClock(time.Now())
+	cache := newLRU(2, WithClock[string, string](clock))
+
+	cache.Set("a", "value a")
+	cache.Set("b", "value b")
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("c")
+	cache.Set("c", "value c")
+	cache.Set("a", "new value a")
+	cache.SetWithTTL("d", "value d", time.Minute)
+	clock.Advance(time.Minute)
+	cache.Get("d")
+	cache.Set("e", "value e")
+	cache.Delete("e")
+	cache.Peek("a")
+	cache.Contains("a")
+	cache.Clear()
+
+	gotStats := cache.Stats()
+	assert.Equal(t, Stats{
+		Hits:       2,
+		Misses:     2,
+		Insertions: 5,
+		Evictions: map[RemovalReason]uint64{
+			ReasonCapacity: 2,
+			ReasonExpired:  1,
+			ReasonDeleted:  1,
+			ReasonCleared:  1,
+		},
+		Replacements: 1,
+	}, gotStats)
+	assert.Equal(t, uint64(4), gotStats.Requests())
+	assert.Equal(t, 0.5, gotStats.HitRatio())
+}
+
+func testLRUCacheTopK(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	t.Run("Least frequently used", func(t *testing.T) {
+		cache := newLRU(4)
+		for i, key := range []string{"a", "b", "c", "d"} {
+			cache.Set(key, "value "+key)
+			for j := 0; j < i; j++ {
+				cache.Get(key)
+			}
+		}
+
+		assert.Equal(t, []KeyHits[string]{{"d", 4}, {"c", 3}}, cache.TopK(2))
+		assert.Equal(t, []KeyHits[string]{{"a", 1}, {"b", 2}, {"c", 3}}, cache.Coldest(3))
+		assert.Equal(t, []KeyHits[string]{{"d", 4}, {"c", 3}, {"b", 2}, {"a", 1}}, cache.TopK(10))
+		assert.Empty(t, cache.TopK(0))
+		assert.Empty(t, cache.Coldest(-1))
+
+		cache.Delete("d")
+		assert.Equal(t, []KeyHits[string]{{"c", 3}}, cache.TopK(1))
+	})
+
+	t.Run("Least recently used", func(t *testing.T) {
+		cache := newLRU(3, WithOrdering[string, string](LeastRecentlyUsed))
+		cache.Set("a", "value a")
+		cache.Set("b", "value b")
+		cache.Set("c", "value c")
+		cache.Get("a")
+
+		assert.Equal(t, []KeyHits[string]{{"a", 2}, {"c", 1}}, cache.TopK(2))
+		assert.Equal(t, []KeyHits[string]{{"b", 1}}, cache.Coldest(1))
+	})
+
+	t.Run("Custom policy", func(t *testing.T) {
+		cache := newLRU(3, WithPolicy[string, string](&fifoPolicy[string]{}))
+		cache.Set("a", "value a")
+
+		assert.Nil(t, cache.TopK(1))
+		assert.Nil(t, cache.Coldest(1))
+	})
+}
+
+func testLRUCacheAging(t *testing.T, newLRU func(capacity int, opts ...Option[string, string]) LRU[string, string]) {
+	// fill fills the cache with a key which was hot long ago followed by keys which are warm now
+	fill := func(cache LRU[string, string]) {
+		cache.Set("hot", "value hot")
+		for i := 0; i < 100; i++ {
+			cache.Get("hot")
+		}
+
+		for i := 0; i < 20; i++ {
+			key := strconv.Itoa(i)
+			cache.Set(key, "value "+key)
+			for j := 0; j < 3; j++ {
+				cache.Get(key)
+			}
+		}
+	}
+
+	cache := newLRU(2)
+	fill(cache)
+	assert.True(t, cache.Contains("hot"), "without aging the hot key stays forever")
+
+	cache = newLRU(2, WithAging[string, string](10))
+	fill(cache)
+	assert.False(t, cache.Contains("hot"), "the former hot key should be evicted")
+	assert.True(t, cache.Contains("19"))
+	for _, key := range cache.TopK(2) {
+		assert.LessOrEqual(t, key.Hits, 10, "hits should be bounded by the aging")
+	}
+
+	cache = newLRU(2, WithOrdering[string, string](LeastRecentlyUsed), WithAging[string, string](10))
+	fill(cache)
+	assert.Equal(t, []string{"19", "18"}, cache.extractPopularityKeys(), "aging doesn't change the recency")
+}
@@ -0,0 +1,110 @@
+// Package lrutest provides helpers for testing the code which uses the lru package
+package lrutest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type fakeTimer struct {
+	at time.Time
+	f  func()
+	// id keeps the timers with the same time in the order they were created
+	id int
+}
+
+// FakeClock is a Clock which moves only when it's told to. It's safe for concurrent use
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	lastID int
+}
+
+// NewFakeClock creates a fake clock which shows the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// AfterFunc schedules f to be called when the clock is advanced by d or more.
+// Unlike time.AfterFunc the function is called synchronously by Advance
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastID++
+	timer := &fakeTimer{at: c.now.Add(d), f: f, id: c.lastID}
+	c.timers = append(c.timers, timer)
+
+	return func() bool {
+		return c.stop(timer)
+	}
+}
+
+// Advance moves the clock forward by d and calls the functions which are due, in the order of their time.
+// Functions scheduled by the called functions are called too if they are due before the new time of the clock
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	until := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		timer := c.nextTimer(until)
+		if timer == nil {
+			return
+		}
+
+		timer.f()
+	}
+}
+
+// nextTimer removes the first timer which is due before until and moves the clock to its time.
+// When there are no such timers it moves the clock to until and returns nil
+func (c *FakeClock) nextTimer(until time.Time) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.Slice(c.timers, func(i, j int) bool {
+		if c.timers[i].at.Equal(c.timers[j].at) {
+			return c.timers[i].id < c.timers[j].id
+		}
+
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	if len(c.timers) == 0 || c.timers[0].at.After(until) {
+		c.now = until
+		return nil
+	}
+
+	timer := c.timers[0]
+	c.timers = c.timers[1:]
+	if timer.at.After(c.now) {
+		c.now = timer.at
+	}
+
+	return timer
+}
+
+func (c *FakeClock) stop(timer *fakeTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.timers {
+		if c.timers[i] == timer {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package lrutest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	var calls []time.Duration
+	record := func() {
+		calls = append(calls, clock.Now().Sub(start))
+	}
+
+	clock.AfterFunc(2*time.Second, record)
+	clock.AfterFunc(time.Second, record)
+	stop := clock.AfterFunc(3*time.Second, record)
+
+	var tick func()
+	tick = func() {
+		calls = append(calls, clock.Now().Sub(start))
+		clock.AfterFunc(1500*time.Millisecond, tick)
+	}
+	clock.AfterFunc(1500*time.Millisecond, tick)
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Empty(t, calls)
+	assert.Equal(t, start.Add(500*time.Millisecond), clock.Now())
+
+	assert.True(t, stop())
+	assert.False(t, stop())
+
+	clock.Advance(2500 * time.Millisecond)
+	assert.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second, 3 * time.Second}, calls)
+	assert.Equal(t, start.Add(3*time.Second), clock.Now())
+}
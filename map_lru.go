@@ -1,115 +1,57 @@
 package lru
 
-type mapLRUItem struct {
-	key             string
-	value           interface{}
-	hits            int
-	morePopularNode *mapLRUItem
-	lessPopularNode *mapLRUItem
-}
-
-type mapLRU struct {
-	capacity       int
-	cache          map[string]*mapLRUItem
-	popularityTail *mapLRUItem
+// mapStorage keeps the entries in a map
+type mapStorage[K comparable, V any] struct {
+	capacity int
+	cache    map[K]*cacheEntry[K, V]
 }
 
 // NewMapLRU creates an instance of the LRU cache with a map as a backend
-func NewMapLRU(capacity int) LRU {
-	if capacity <= 0 {
-		capacity = 1
-	}
+func NewMapLRU[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return newCache(capacity, newMapStorage[K, V], opts)
+}
 
-	return &mapLRU{
+func newMapStorage[K comparable, V any](capacity int) storage[K, V] {
+	return &mapStorage[K, V]{
 		capacity: capacity,
-		cache:    make(map[string]*mapLRUItem, capacity),
+		cache:    make(map[K]*cacheEntry[K, V], capacity),
 	}
 }
 
-func (m *mapLRU) Get(key string) (found bool, value interface{}) {
-	if item, ok := m.cache[key]; ok {
-		item.hits++
-		m.swap(item)
-		return ok, item.value
-	}
-
-	return false, nil
+func (m *mapStorage[K, V]) get(key K) *cacheEntry[K, V] {
+	return m.cache[key]
 }
 
-func (m *mapLRU) Set(key string, value interface{}) {
-	if item, ok := m.cache[key]; ok {
-		item.hits++
-		m.swap(item)
-		return
-	}
+func (m *mapStorage[K, V]) add(entry *cacheEntry[K, V]) {
+	m.cache[entry.key] = entry
+}
 
-	if len(m.cache) == m.capacity {
-		m.evict()
+func (m *mapStorage[K, V]) remove(key K) *cacheEntry[K, V] {
+	entry, ok := m.cache[key]
+	if !ok {
+		return nil
 	}
 
-	newItem := &mapLRUItem{
-		key:             key,
-		value:           value,
-		hits:            1,
-		morePopularNode: m.popularityTail,
-	}
-	m.cache[key] = newItem
-	if m.popularityTail != nil {
-		m.popularityTail.lessPopularNode = newItem
-	}
-	m.popularityTail = newItem
+	delete(m.cache, key)
+	return entry
 }
 
-func (m *mapLRU) Size() int {
+func (m *mapStorage[K, V]) len() int {
 	return len(m.cache)
 }
 
-func (m *mapLRU) extractPopularityKeys() []string {
-	keys := make([]string, 0, len(m.cache))
-
-	for item := m.popularityTail; item != nil; item = item.morePopularNode {
-		keys = append([]string{item.key}, keys...)
-	}
+func (m *mapStorage[K, V]) backend() string {
+	return "map"
+}
 
-	return keys
+func (m *mapStorage[K, V]) clear() {
+	m.cache = make(map[K]*cacheEntry[K, V], m.capacity)
 }
 
-func (m *mapLRU) swap(item *mapLRUItem) {
-	for {
-		if item == nil || item.morePopularNode == nil || item.hits <= item.morePopularNode.hits {
+func (m *mapStorage[K, V]) each(fn func(entry *cacheEntry[K, V]) bool) {
+	for _, entry := range m.cache {
+		if !fn(entry) {
 			return
 		}
-
-		nextNode := item.morePopularNode
-		nextNextNode := nextNode.morePopularNode
-
-		nextNode.morePopularNode = item
-
-		item.morePopularNode = nextNextNode
-		if item.morePopularNode != nil {
-			item.morePopularNode.lessPopularNode = item
-		}
-
-		nextNode.lessPopularNode = item.lessPopularNode
-		if nextNode.lessPopularNode != nil {
-			nextNode.lessPopularNode.morePopularNode = nextNode
-		}
-
-		item.lessPopularNode = nextNode
-
-		if m.popularityTail == item {
-			m.popularityTail = nextNode
-		}
-	}
-}
-
-func (m *mapLRU) evict() {
-	if len(m.cache) < m.capacity || m.popularityTail == nil {
-		return
 	}
-
-	item := m.popularityTail
-	m.popularityTail = item.morePopularNode
-
-	delete(m.cache, item.key)
 }
@@ -5,5 +5,69 @@ import (
 )
 
 func TestMapLRUCache(t *testing.T) {
-	testLRUCache(t, NewMapLRU)
+	testLRUCache(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_IntKeys(t *testing.T) {
+	testLRUCacheIntKeys(t, NewMapLRU[int, []byte])
+}
+
+func TestMapLRUCache_Delete(t *testing.T) {
+	testLRUCacheDelete(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Peek(t *testing.T) {
+	testLRUCachePeek(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Clear(t *testing.T) {
+	testLRUCacheClear(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Replace(t *testing.T) {
+	testLRUCacheReplace(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_LeastRecentlyUsed(t *testing.T) {
+	testLRUCacheLeastRecentlyUsed(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_CustomPolicy(t *testing.T) {
+	testLRUCacheCustomPolicy(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_TTL(t *testing.T) {
+	testLRUCacheTTL(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_ExpireAfterAccess(t *testing.T) {
+	testLRUCacheExpireAfterAccess(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_OnEvict(t *testing.T) {
+	testLRUCacheOnEvict(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_SetAndEvict(t *testing.T) {
+	testLRUCacheSetAndEvict(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Stats(t *testing.T) {
+	testLRUCacheStats(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_TopK(t *testing.T) {
+	testLRUCacheTopK(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Aging(t *testing.T) {
+	testLRUCacheAging(t, NewMapLRU[string, string])
+}
+
+func TestMapLRUCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewMapLRU[int, int])
+}
+
+func TestMapLRUCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewMapLRU[int, int])
 }
@@ -0,0 +1,163 @@
+package lru
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StatsProvider is a cache which reports its statistics. All caches of the package, including LoadingCache, are providers
+type StatsProvider interface {
+	Stats() Stats
+	Size() int
+}
+
+// backendNamer is implemented by the caches which know the name of their storage
+type backendNamer interface {
+	backend() string
+}
+
+// backendOf returns the name of the storage of the cache, it's "unknown" for the caches created outside the package
+func backendOf(cache any) string {
+	if namer, ok := cache.(backendNamer); ok {
+		return namer.backend()
+	}
+
+	return "unknown"
+}
+
+// Metrics renders the statistics of the registered caches in the Prometheus text exposition format.
+// It's an http.Handler, so it can be mounted as the scrape endpoint
+type Metrics struct {
+	mu     sync.Mutex
+	caches map[string]StatsProvider
+}
+
+// NewMetrics creates an empty registry of caches
+func NewMetrics() *Metrics {
+	return &Metrics{caches: make(map[string]StatsProvider)}
+}
+
+// Register adds the cache under the name, which becomes the value of the cache label.
+// It returns an error if the name is already taken
+func (m *Metrics) Register(name string, cache StatsProvider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.caches[name]; ok {
+		return fmt.Errorf("cache %q is already registered", name)
+	}
+
+	m.caches[name] = cache
+	return nil
+}
+
+// Unregister removes the cache registered under the name
+func (m *Metrics) Unregister(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.caches, name)
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteMetrics(w)
+}
+
+type metricSample struct {
+	labels string
+	value  string
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+// WriteMetrics writes the statistics of the registered caches to w, the caches are sorted by their names
+func (m *Metrics) WriteMetrics(w io.Writer) error {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	caches := make([]StatsProvider, len(names))
+	for i, name := range names {
+		caches[i] = m.caches[name]
+	}
+	m.mu.Unlock()
+
+	families := []*metricFamily{
+		{name: "lru_cache_hits_total", help: "Number of Get calls which found the key.", kind: "counter"},
+		{name: "lru_cache_misses_total", help: "Number of Get calls which didn't find the key.", kind: "counter"},
+		{name: "lru_cache_hit_ratio", help: "Share of Get calls which found the key.", kind: "gauge"},
+		{name: "lru_cache_insertions_total", help: "Number of new keys added to the cache.", kind: "counter"},
+		{name: "lru_cache_evictions_total", help: "Number of items which left the cache by the reason.", kind: "counter"},
+		{name: "lru_cache_replacements_total", help: "Number of values replaced by new values of the same keys.", kind: "counter"},
+		{name: "lru_cache_load_successes_total", help: "Number of values loaded successfully.", kind: "counter"},
+		{name: "lru_cache_load_failures_total", help: "Number of loads which returned an error.", kind: "counter"},
+		{name: "lru_cache_load_duration_seconds_total", help: "Total time spent loading values.", kind: "counter"},
+		{name: "lru_cache_size", help: "Number of items in the cache.", kind: "gauge"},
+	}
+	hits, misses, hitRatio, insertions, evictions := families[0], families[1], families[2], families[3], families[4]
+	replacements, loadSuccesses, loadFailures, loadDuration, size := families[5], families[6], families[7], families[8], families[9]
+
+	for i, cache := range caches {
+		labels := `cache="` + escapeLabelValue(names[i]) + `",backend="` + escapeLabelValue(backendOf(cache)) + `"`
+		stats := cache.Stats()
+
+		hits.add(labels, formatUint(stats.Hits))
+		misses.add(labels, formatUint(stats.Misses))
+		hitRatio.add(labels, formatFloat(stats.HitRatio()))
+		insertions.add(labels, formatUint(stats.Insertions))
+		for _, reason := range evictionReasons {
+			evictions.add(labels+`,reason="`+reason.String()+`"`, formatUint(stats.Evictions[reason]))
+		}
+		replacements.add(labels, formatUint(stats.Replacements))
+		loadSuccesses.add(labels, formatUint(stats.LoadSuccesses))
+		loadFailures.add(labels, formatUint(stats.LoadFailures))
+		loadDuration.add(labels, formatFloat(stats.LoadTime.Seconds()))
+		size.add(labels, strconv.Itoa(cache.Size()))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		family.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+func (f *metricFamily) add(labels, value string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+func (f *metricFamily) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	for _, sample := range f.samples {
+		fmt.Fprintf(w, "%s{%s} %s\n", f.name, sample.labels, sample.value)
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatUint(value uint64) string {
+	return strconv.FormatUint(value, 10)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,127 @@
+package lru
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseMetrics parses the text exposition format into the values of the samples keyed by the name and the labels.
+// It fails the test if a sample isn't preceded by the HELP and TYPE lines of its metric
+func parseMetrics(t *testing.T, text string) map[string]float64 {
+	t.Helper()
+
+	samples := make(map[string]float64)
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			if assert.Len(t, fields, 4, line) {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+
+		i := strings.LastIndexByte(line, ' ')
+		if !assert.Greater(t, i, 0, line) {
+			continue
+		}
+
+		series, value := line[:i], line[i+1:]
+		name, _, _ := strings.Cut(series, "{")
+		assert.Contains(t, types, name, "the type of %s should be declared before its samples", name)
+
+		parsed, err := strconv.ParseFloat(value, 64)
+		assert.NoError(t, err, line)
+		assert.NotContains(t, samples, series, "duplicate series")
+		samples[series] = parsed
+	}
+
+	assert.NoError(t, scanner.Err())
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	users := NewMapLRU[string, string](2)
+	users.Set("a", "value a")
+	users.Set("b", "value b")
+	users.Set("c", "value c")
+	users.Get("c")
+	users.Get("d")
+	users.Get("e")
+	users.Get("f")
+
+	sessions := NewLoadingCache(NewConcurrent(NewBintreeLRU[int, string](10)))
+	sessions.Set(1, "session 1")
+	sessions.Delete(1)
+
+	metrics := NewMetrics()
+	assert.NoError(t, metrics.Register("users", users))
+	assert.NoError(t, metrics.Register(`odd "name"`, NewShardedLRU(2, 10, NewListLRU[string, int])))
+	assert.NoError(t, metrics.Register("sessions", sessions))
+	assert.Error(t, metrics.Register("users", users), "names should be unique")
+
+	var out strings.Builder
+	assert.NoError(t, metrics.WriteMetrics(&out))
+	samples := parseMetrics(t, out.String())
+
+	assert.Equal(t, 1.0, samples[`lru_cache_hits_total{cache="users",backend="map"}`])
+	assert.Equal(t, 3.0, samples[`lru_cache_misses_total{cache="users",backend="map"}`])
+	assert.Equal(t, 0.25, samples[`lru_cache_hit_ratio{cache="users",backend="map"}`])
+	assert.Equal(t, 3.0, samples[`lru_cache_insertions_total{cache="users",backend="map"}`])
+	assert.Equal(t, 1.0, samples[`lru_cache_evictions_total{cache="users",backend="map",reason="capacity"}`])
+	assert.Equal(t, 0.0, samples[`lru_cache_evictions_total{cache="users",backend="map",reason="deleted"}`])
+	assert.Equal(t, 2.0, samples[`lru_cache_size{cache="users",backend="map"}`])
+
+	assert.Equal(t, 1.0, samples[`lru_cache_evictions_total{cache="sessions",backend="bintree",reason="deleted"}`])
+	assert.Equal(t, 0.0, samples[`lru_cache_size{cache="sessions",backend="bintree"}`])
+	assert.Equal(t, 0.0, samples[`lru_cache_size{cache="odd \"name\"",backend="list"}`])
+
+	for _, metric := range []string{"hits_total", "misses_total", "hit_ratio", "insertions_total", "load_successes_total",
+		"load_failures_total", "load_duration_seconds_total", "size"} {
+		assert.Contains(t, samples, `lru_cache_`+metric+`{cache="sessions",backend="bintree"}`)
+	}
+	assert.Len(t, samples, 3*(9+len(evictionReasons)))
+
+	metrics.Unregister("users")
+	out.Reset()
+	assert.NoError(t, metrics.WriteMetrics(&out))
+	assert.NotContains(t, parseMetrics(t, out.String()), `lru_cache_size{cache="users",backend="map"}`)
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	sessions := NewLoadingCache(NewSynchronized(NewMapLRU[string, string](10)))
+	sessions.loadSuccesses.Add(4)
+	sessions.loadTime.Add(int64(2 * time.Second))
+
+	metrics := NewMetrics()
+	assert.NoError(t, metrics.Register("sessions", sessions))
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	samples := parseMetrics(t, recorder.Body.String())
+	assert.Equal(t, 4.0, samples[`lru_cache_load_successes_total{cache="sessions",backend="map"}`])
+	assert.Equal(t, 2.0, samples[`lru_cache_load_duration_seconds_total{cache="sessions",backend="map"}`])
+}
+
+func TestBackendOf(t *testing.T) {
+	assert.Equal(t, "map", backendOf(NewSynchronized(NewMapLRU[string, string](1))))
+	assert.Equal(t, "list", backendOf(NewConcurrent(NewListLRU[string, string](1))))
+	assert.Equal(t, "bintree", backendOf(NewShardedLRU(2, 1, NewBintreeLRU[string, string])))
+	assert.Equal(t, "unknown", backendOf(struct{}{}))
+}
@@ -0,0 +1,177 @@
+package lru
+
+import "time"
+
+// Option configures a cache created by one of the constructors
+type Option[K comparable, V any] func(*options[K, V])
+
+type options[K comparable, V any] struct {
+	keepExistingValues bool
+	ordering           Ordering
+	agingPeriod        int
+	twoQueueIn         float64
+	twoQueueOut        float64
+	doorkeeper         bool
+	policy             Policy[K]
+	policyFactory      func(capacity int) Policy[K]
+	ttl                time.Duration
+	expireAfterAccess  time.Duration
+	janitorInterval    time.Duration
+	janitorSweepLimit  int
+	clock              Clock
+	refreshAfterWrite  time.Duration
+	onEvict            func(key K, value V, reason RemovalReason)
+}
+
+func newOptions[K comparable, V any](opts []Option[K, V]) options[K, V] {
+	o := options[K, V]{clock: SystemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// KeepExistingValues makes Set keep the value of a key which is already in the cache instead of replacing it.
+// Replace always replaces the value
+func KeepExistingValues[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.keepExistingValues = true
+	}
+}
+
+// Ordering defines which item the cache evicts when it runs out of capacity
+type Ordering int
+
+const (
+	// LeastFrequentlyUsed orders the items by the number of hits and evicts the item with the fewest of them.
+	// This is the default ordering
+	LeastFrequentlyUsed Ordering = iota
+	// LeastRecentlyUsed moves an item to the front on every access and evicts the item which wasn't accessed for the longest time
+	LeastRecentlyUsed
+	// AdaptiveReplacement balances the recency and the frequency of accesses with ARC, see NewARCPolicy
+	AdaptiveReplacement
+	// TwoQueue keeps the new items in a probationary queue and promotes them when they come back, see NewTwoQueuePolicy
+	TwoQueue
+	// TinyLFU admits new items to the cache only when they are used more often than the items they replace,
+	// see NewTinyLFUPolicy
+	TinyLFU
+	// LowInterReferenceRecency keeps the items which are reused after the shortest gaps with LIRS, it doesn't thrash
+	// on loops bigger than the cache, see NewLIRSPolicy
+	LowInterReferenceRecency
+)
+
+func newOrderingPolicy[K comparable, V any](o options[K, V], capacity int) Policy[K] {
+	switch o.ordering {
+	case LeastRecentlyUsed:
+		policy := &lruPolicy[K]{nodes: make(map[K]*popularityNode[K])}
+		policy.list.agingPeriod = o.agingPeriod
+		return policy
+	case AdaptiveReplacement:
+		return NewARCPolicy[K](capacity)
+	case TwoQueue:
+		return NewTwoQueuePolicy[K](capacity, o.twoQueueIn, o.twoQueueOut)
+	case TinyLFU:
+		return NewTinyLFUPolicy[K](capacity, o.doorkeeper)
+	case LowInterReferenceRecency:
+		return NewLIRSPolicy[K](capacity)
+	default:
+		return NewAgingLFUPolicy[K](o.agingPeriod)
+	}
+}
+
+// WithOrdering sets the ordering of the items in the cache. It's a shortcut for WithPolicy with one of the built-in policies
+func WithOrdering[K comparable, V any](ordering Ordering) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ordering = ordering
+	}
+}
+
+// WithAging halves the hits of all items after every period reads and writes, so items which were popular long ago
+// lose their rank and can be evicted. With the LeastRecentlyUsed ordering it only keeps the hits reported by TopK
+// fresh. Every aging walks over all items, so the period should be several times bigger than the capacity
+func WithAging[K comparable, V any](period int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.agingPeriod = period
+	}
+}
+
+// WithTwoQueueRatios sets the sizes of the queues of the TwoQueue ordering as shares of the capacity: in is the share
+// of the probationary queue of new items and out is the size of the queue of evicted keys
+func WithTwoQueueRatios[K comparable, V any](in, out float64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.twoQueueIn = in
+		o.twoQueueOut = out
+	}
+}
+
+// WithDoorkeeper puts a Bloom filter in front of the frequency sketch of the TinyLFU ordering
+func WithDoorkeeper[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.doorkeeper = true
+	}
+}
+
+// WithPolicy sets the eviction policy of the cache. It takes precedence over WithOrdering
+func WithPolicy[K comparable, V any](policy Policy[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.policy = policy
+	}
+}
+
+// WithPolicyFactory sets the function which creates the eviction policy for the capacity of the cache. Every cache
+// created with the option gets its own policy, so it works with NewShardedLRU, where WithPolicy can't be used.
+// WithPolicy takes precedence over it
+func WithPolicyFactory[K comparable, V any](factory func(capacity int) Policy[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.policyFactory = factory
+	}
+}
+
+// WithTTL sets the default time to live of the items added with Set or Replace. By default items never expire
+func WithTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ttl = ttl
+	}
+}
+
+// WithExpireAfterAccess makes the items expire when they aren't accessed for the idle duration. Every Get, Set or Replace
+// of an item extends its life, Peek and Contains don't. Items with TTL expire at the end of the TTL at the latest
+func WithExpireAfterAccess[K comparable, V any](idle time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.expireAfterAccess = idle
+	}
+}
+
+// WithJanitor starts a background goroutine which removes expired items from the cache every interval. A single sweep
+// removes at most sweepLimit items to keep the cache responsive, there is no limit when sweepLimit isn't positive.
+// The cache guards itself with a mutex when the janitor is running. Close stops the janitor
+func WithJanitor[K comparable, V any](interval time.Duration, sweepLimit int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.janitorInterval = interval
+		o.janitorSweepLimit = sweepLimit
+	}
+}
+
+// WithClock sets the source of time of the cache, it's used for expiration and by the janitor
+func WithClock[K comparable, V any](clock Clock) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.clock = clock
+	}
+}
+
+// WithRefreshAfterWrite makes the LoadingCache reload the values which are older than age in the background.
+// Until the new value is loaded callers get the old one
+func WithRefreshAfterWrite[K comparable, V any](age time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.refreshAfterWrite = age
+	}
+}
+
+// WithOnEvict sets the listener which is called for every item leaving the cache, including replaced values.
+// The listener is called after the cache has released its locks, so it may use the cache
+func WithOnEvict[K comparable, V any](onEvict func(key K, value V, reason RemovalReason)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvict = onEvict
+	}
+}
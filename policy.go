@@ -0,0 +1,21 @@
+package lru
+
+// Policy decides which key the cache evicts when it runs out of capacity. The cache notifies the policy about every
+// change of its content and asks it for a victim when it needs room for a new key.
+//
+// A policy keeps the state of a single cache and can't be shared between caches
+type Policy[K comparable] interface {
+	// OnInsert is called when the key is added to the cache
+	OnInsert(key K)
+	// OnAccess is called when the key is read or its value is updated
+	OnAccess(key K)
+	// OnRemove is called when the key is removed from the cache
+	OnRemove(key K)
+	// Victim returns the key which should be evicted next, it doesn't remove the key from the policy
+	Victim() (K, bool)
+}
+
+// missObserver is implemented by the policies which want to know about a new key before the cache picks a victim for it
+type missObserver[K comparable] interface {
+	onMiss(key K)
+}
@@ -0,0 +1,122 @@
+package lru
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type policyOperation struct {
+	op  string
+	key string
+}
+
+func parsePolicyOperations(ops string) []policyOperation {
+	var operations []policyOperation
+	for _, op := range strings.Fields(ops) {
+		operations = append(operations, policyOperation{op: op[:1], key: op[1:]})
+	}
+
+	return operations
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		newPolicy    func() Policy[string]
+		operations   string
+		wantVictim   string
+		wantFound    bool
+		wantPriority []string
+	}{
+		{
+			name:         "lfu, empty",
+			newPolicy:    NewLFUPolicy[string],
+			wantPriority: []string{},
+		},
+		{
+			name:         "lfu, new keys are the victims",
+			newPolicy:    NewLFUPolicy[string],
+			operations:   "ia ib ic",
+			wantVictim:   "c",
+			wantFound:    true,
+			wantPriority: []string{"a", "b", "c"},
+		},
+		{
+			name:         "lfu, accesses make keys more popular",
+			newPolicy:    NewLFUPolicy[string],
+			operations:   "ia ib ic ac ac ab",
+			wantVictim:   "a",
+			wantFound:    true,
+			wantPriority: []string{"c", "b", "a"},
+		},
+		{
+			name:         "lfu, removal",
+			newPolicy:    NewLFUPolicy[string],
+			operations:   "ia ib ic ac ac ab ra rc",
+			wantVictim:   "b",
+			wantFound:    true,
+			wantPriority: []string{"b"},
+		},
+		{
+			name:         "aging lfu, old hits are halved",
+			newPolicy:    func() Policy[string] { return NewAgingLFUPolicy[string](4) },
+			operations:   "ia aa aa aa ib ab ab",
+			wantVictim:   "a",
+			wantFound:    true,
+			wantPriority: []string{"b", "a"},
+		},
+		{
+			name:         "lru, empty",
+			newPolicy:    NewLRUPolicy[string],
+			wantPriority: []string{},
+		},
+		{
+			name:         "lru, the oldest key is the victim",
+			newPolicy:    NewLRUPolicy[string],
+			operations:   "ia ib ic",
+			wantVictim:   "a",
+			wantFound:    true,
+			wantPriority: []string{"c", "b", "a"},
+		},
+		{
+			name:         "lru, accesses make keys recent",
+			newPolicy:    NewLRUPolicy[string],
+			operations:   "ia ib ic ab aa aa",
+			wantVictim:   "c",
+			wantFound:    true,
+			wantPriority: []string{"a", "b", "c"},
+		},
+		{
+			name:         "lru, removal",
+			newPolicy:    NewLRUPolicy[string],
+			operations:   "ia ib ic ab aa rc rb",
+			wantVictim:   "a",
+			wantFound:    true,
+			wantPriority: []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		tt := test
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.newPolicy()
+			for _, operation := range parsePolicyOperations(tt.operations) {
+				switch operation.op {
+				case "i":
+					policy.OnInsert(operation.key)
+				case "a":
+					policy.OnAccess(operation.key)
+				case "r":
+					policy.OnRemove(operation.key)
+				}
+			}
+
+			gotVictim, gotFound := policy.Victim()
+			assert.Equal(t, tt.wantFound, gotFound)
+			assert.Equal(t, tt.wantVictim, gotVictim)
+			assert.Equal(t, tt.wantPriority, policy.(lruPopularityExtractor[string]).extractPopularityKeys())
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package lru
+
+type popularityNode[K comparable] struct {
+	key             K
+	hits            int
+	morePopularNode *popularityNode[K]
+	lessPopularNode *popularityNode[K]
+}
+
+// queueEntry is a node of the policies which move the keys between several lists
+type queueEntry[K comparable] struct {
+	node popularityNode[K]
+	// list is the list the key is in, the policies keep both resident and ghost keys in the lists
+	list *popularityList[K]
+}
+
+// popularityList is a doubly linked list of keys ordered from the most popular (head) to the least popular one (tail)
+type popularityList[K comparable] struct {
+	popularityHead *popularityNode[K]
+	popularityTail *popularityNode[K]
+	size           int
+	// agingPeriod is the number of accesses after which the hits of all keys are halved, there is no aging when it's 0
+	agingPeriod int
+	accesses    int
+}
+
+// age counts an access and halves the hits of all keys when the aging period is over. Halving keeps the order
+// of the keys, so the list stays sorted. The hits don't go below 1, which is the hits of a new key
+func (p *popularityList[K]) age() {
+	if p.agingPeriod <= 0 {
+		return
+	}
+
+	p.accesses++
+	if p.accesses < p.agingPeriod {
+		return
+	}
+
+	p.accesses = 0
+	for node := p.popularityHead; node != nil; node = node.lessPopularNode {
+		node.hits = max(node.hits/2, 1)
+	}
+}
+
+func (p *popularityList[K]) pushFront(node *popularityNode[K]) {
+	node.lessPopularNode = p.popularityHead
+	if p.popularityHead != nil {
+		p.popularityHead.morePopularNode = node
+	}
+	p.popularityHead = node
+
+	if p.popularityTail == nil {
+		p.popularityTail = node
+	}
+	p.size++
+}
+
+func (p *popularityList[K]) pushBack(node *popularityNode[K]) {
+	node.morePopularNode = p.popularityTail
+	if p.popularityTail != nil {
+		p.popularityTail.lessPopularNode = node
+	}
+	p.popularityTail = node
+
+	if p.popularityHead == nil {
+		p.popularityHead = node
+	}
+	p.size++
+}
+
+func (p *popularityList[K]) unlink(node *popularityNode[K]) {
+	if node.morePopularNode != nil {
+		node.morePopularNode.lessPopularNode = node.lessPopularNode
+	}
+
+	if node.lessPopularNode != nil {
+		node.lessPopularNode.morePopularNode = node.morePopularNode
+	}
+
+	if p.popularityTail == node {
+		p.popularityTail = node.morePopularNode
+	}
+
+	if p.popularityHead == node {
+		p.popularityHead = node.lessPopularNode
+	}
+
+	node.morePopularNode = nil
+	node.lessPopularNode = nil
+	p.size--
+}
+
+func (p *popularityList[K]) moveToFront(node *popularityNode[K]) {
+	if p.popularityHead == node {
+		return
+	}
+
+	p.unlink(node)
+	p.pushFront(node)
+}
+
+// swap moves the node towards the head while it has more hits than the node in front of it
+func (p *popularityList[K]) swap(node *popularityNode[K]) {
+	for {
+		if node == nil || node.morePopularNode == nil || node.hits <= node.morePopularNode.hits {
+			return
+		}
+
+		nextNode := node.morePopularNode
+		nextNextNode := nextNode.morePopularNode
+
+		nextNode.morePopularNode = node
+
+		node.morePopularNode = nextNextNode
+		if node.morePopularNode != nil {
+			node.morePopularNode.lessPopularNode = node
+		}
+
+		nextNode.lessPopularNode = node.lessPopularNode
+		if nextNode.lessPopularNode != nil {
+			nextNode.lessPopularNode.morePopularNode = nextNode
+		}
+
+		node.lessPopularNode = nextNode
+
+		if p.popularityTail == node {
+			p.popularityTail = nextNode
+		}
+
+		if p.popularityHead == nextNode {
+			p.popularityHead = node
+		}
+	}
+}
+
+func (p *popularityList[K]) clear() {
+	p.popularityHead = nil
+	p.popularityTail = nil
+	p.size = 0
+}
+
+// topK returns up to n keys walking from the head
+func (p *popularityList[K]) topK(n int) []KeyHits[K] {
+	keys := make([]KeyHits[K], 0, min(max(n, 0), p.size))
+	for node := p.popularityHead; node != nil && len(keys) < n; node = node.lessPopularNode {
+		keys = append(keys, KeyHits[K]{Key: node.key, Hits: node.hits})
+	}
+
+	return keys
+}
+
+// coldest returns up to n keys walking from the tail
+func (p *popularityList[K]) coldest(n int) []KeyHits[K] {
+	keys := make([]KeyHits[K], 0, min(max(n, 0), p.size))
+	for node := p.popularityTail; node != nil && len(keys) < n; node = node.morePopularNode {
+		keys = append(keys, KeyHits[K]{Key: node.key, Hits: node.hits})
+	}
+
+	return keys
+}
+
+func (p *popularityList[K]) extractPopularityKeys() []K {
+	keys := make([]K, 0, p.size)
+	for node := p.popularityHead; node != nil; node = node.lessPopularNode {
+		keys = append(keys, node.key)
+	}
+
+	return keys
+}
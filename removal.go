@@ -0,0 +1,105 @@
+package lru
+
+import "sync"
+
+// RemovalReason tells why an item has left the cache
+type RemovalReason int
+
+const (
+	// ReasonCapacity means the item was evicted to make room for a new one
+	ReasonCapacity RemovalReason = iota + 1
+	// ReasonExpired means the item has outlived its TTL or idle timeout
+	ReasonExpired
+	// ReasonDeleted means the item was removed with Delete
+	ReasonDeleted
+	// ReasonReplaced means the value was replaced with a new one by Set or Replace
+	ReasonReplaced
+	// ReasonCleared means the item was removed with Clear
+	ReasonCleared
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonExpired:
+		return "expired"
+	case ReasonDeleted:
+		return "deleted"
+	case ReasonReplaced:
+		return "replaced"
+	case ReasonCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+type removal[K comparable, V any] struct {
+	key    K
+	value  V
+	reason RemovalReason
+}
+
+// removalNotifier is implemented by the caches which report removed items to the eviction listener.
+// The notifications are sent after the cache releases its locks. A wrapper which guards the cache with a lock of its own
+// defers the notifications of the cache and flushes them after releasing its lock
+type removalNotifier interface {
+	deferRemovals()
+	flushRemovals()
+}
+
+// removals collects the removed items until they can be sent to the listener
+type removals[K comparable, V any] struct {
+	listener func(key K, value V, reason RemovalReason)
+	deferred bool
+
+	mu      sync.Mutex
+	pending []removal[K, V]
+}
+
+func (r *removals[K, V]) add(key K, value V, reason RemovalReason) {
+	if r.listener == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.pending = append(r.pending, removal[K, V]{key: key, value: value, reason: reason})
+	r.mu.Unlock()
+}
+
+// notify sends the pending notifications unless they are deferred
+func (r *removals[K, V]) notify() {
+	if r.listener != nil && !r.deferred {
+		r.flush()
+	}
+}
+
+func (r *removals[K, V]) flush() {
+	if r.listener == nil {
+		return
+	}
+
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	for _, removal := range pending {
+		r.listener(removal.key, removal.value, removal.reason)
+	}
+}
+
+// deferRemovalsOf defers the notifications of the wrapped cache if it sends them
+func deferRemovalsOf[K comparable, V any](lru LRU[K, V]) {
+	if notifier, ok := lru.(removalNotifier); ok {
+		notifier.deferRemovals()
+	}
+}
+
+// flushRemovalsOf sends the deferred notifications of the wrapped cache if it sends them
+func flushRemovalsOf[K comparable, V any](lru LRU[K, V]) {
+	if notifier, ok := lru.(removalNotifier); ok {
+		notifier.flushRemovals()
+	}
+}
@@ -0,0 +1,102 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/melan/go-lru/lrutest"
+)
+
+func TestRemovalReason_String(t *testing.T) {
+	tests := []struct {
+		reason RemovalReason
+		want   string
+	}{
+		{ReasonCapacity, "capacity"},
+		{ReasonExpired, "expired"},
+		{ReasonDeleted, "deleted"},
+		{ReasonReplaced, "replaced"},
+		{ReasonCleared, "cleared"},
+		{RemovalReason(0), "unknown"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.reason.String())
+	}
+}
+
+// TestOnEvict_ReentrantListener checks that the listener is called outside the locks, so it can use the cache
+func TestOnEvict_ReentrantListener(t *testing.T) {
+	tests := []struct {
+		name     string
+		newCache func(opts ...Option[string, string]) LRU[string, string]
+	}{
+		{"Plain", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewMapLRU[string, string](2, opts...)
+		}},
+		{"Synchronized", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewSynchronized(NewListLRU[string, string](2, opts...))
+		}},
+		{"Concurrent", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewConcurrent(NewBintreeLRU[string, string](2, opts...))
+		}},
+		{"Concurrent synchronized", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewConcurrent(NewSynchronized(NewMapLRU[string, string](2, opts...)))
+		}},
+		{"Sharded", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewShardedLRU(1, 2, NewMapLRU[string, string], opts...)
+		}},
+		{"Janitor", func(opts ...Option[string, string]) LRU[string, string] {
+			return NewMapLRU[string, string](2, append(opts, WithJanitor[string, string](time.Hour, 0))...)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cache LRU[string, string]
+			var removed []string
+			cache = tt.newCache(WithOnEvict(func(key string, value string, reason RemovalReason) {
+				removed = append(removed, key+" "+reason.String())
+				// the listener may access the cache, it would deadlock if it was called under a lock
+				cache.Contains(key)
+				cache.Size()
+			}))
+			defer cache.Close()
+
+			cache.Set("a", "value a")
+			cache.Set("b", "value b")
+			cache.Set("c", "value c")
+			cache.Delete("c")
+			cache.Clear()
+
+			assert.Equal(t, []string{"b capacity", "c deleted", "a cleared"}, removed)
+		})
+	}
+}
+
+func TestOnEvict_Janitor(t *testing.T) {
+	clock := lrutest.NewFakeClock(time.Now())
+	removed := make(chan string, 2)
+	cache := NewMapLRU[string, string](2,
+		WithClock[string, string](clock),
+		WithJanitor[string, string](time.Minute, 0),
+		WithOnEvict(func(key string, value string, reason RemovalReason) {
+			assert.Equal(t, ReasonExpired, reason)
+			removed <- key
+		}))
+	defer cache.Close()
+
+	cache.SetWithTTL("a", "value a", time.Second)
+	cache.Set("b", "value b")
+	clock.Advance(time.Minute)
+
+	select {
+	case key := <-removed:
+		assert.Equal(t, "a", key)
+	default:
+		assert.Fail(t, "the janitor should report the expired item")
+	}
+	assert.Equal(t, 1, cache.Size())
+}
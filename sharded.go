@@ -0,0 +1,171 @@
+package lru
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+	"time"
+)
+
+// Factory creates a cache with the given capacity. All constructors of the package can be used as factories
+type Factory[K comparable, V any] func(capacity int, opts ...Option[K, V]) LRU[K, V]
+
+// shardedLRU spreads the keys over independent caches, so goroutines working with different keys don't wait for each other
+type shardedLRU[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []LRU[K, V]
+}
+
+// NewShardedLRU creates a cache which consists of the given number of shards with capacityPerShard each. The shards are
+// created by the factory with the options and are guarded by their own mutexes, so the cache is safe for concurrent use.
+// Every shard gets its own copy of the options. A policy can't be shared between the shards, so NewShardedLRU panics
+// when the options contain WithPolicy, use WithPolicyFactory to give every shard its own policy
+func NewShardedLRU[K comparable, V any](shards, capacityPerShard int, factory Factory[K, V], opts ...Option[K, V]) LRU[K, V] {
+	if newOptions(opts).policy != nil {
+		panic("lru: WithPolicy can't be used with NewShardedLRU, use WithPolicyFactory")
+	}
+
+	if shards <= 0 {
+		shards = 1
+	}
+
+	s := &shardedLRU[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]LRU[K, V], shards),
+	}
+
+	for i := range s.shards {
+		s.shards[i] = NewSynchronized(factory(capacityPerShard, opts...))
+	}
+
+	return s
+}
+
+func (s *shardedLRU[K, V]) shard(key K) LRU[K, V] {
+	return s.shards[maphash.Comparable(s.seed, key)%uint64(len(s.shards))]
+}
+
+func (s *shardedLRU[K, V]) Get(key K) (bool, V) {
+	return s.shard(key).Get(key)
+}
+
+func (s *shardedLRU[K, V]) Set(key K, value V) {
+	s.shard(key).Set(key, value)
+}
+
+func (s *shardedLRU[K, V]) SetAndEvict(key K, value V) (K, V, bool) {
+	return s.shard(key).SetAndEvict(key, value)
+}
+
+func (s *shardedLRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (s *shardedLRU[K, V]) Replace(key K, value V) (bool, V) {
+	return s.shard(key).Replace(key, value)
+}
+
+func (s *shardedLRU[K, V]) Size() int {
+	size := 0
+	for _, shard := range s.shards {
+		size += shard.Size()
+	}
+
+	return size
+}
+
+func (s *shardedLRU[K, V]) Delete(key K) bool {
+	return s.shard(key).Delete(key)
+}
+
+func (s *shardedLRU[K, V]) Peek(key K) (bool, V) {
+	return s.shard(key).Peek(key)
+}
+
+func (s *shardedLRU[K, V]) Contains(key K) bool {
+	return s.shard(key).Contains(key)
+}
+
+func (s *shardedLRU[K, V]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+func (s *shardedLRU[K, V]) Stats() Stats {
+	var stats Stats
+	for _, shard := range s.shards {
+		stats = stats.merge(shard.Stats())
+	}
+
+	return stats
+}
+
+func (s *shardedLRU[K, V]) Close() {
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+}
+
+func (s *shardedLRU[K, V]) limit() int {
+	capacity := 0
+	for _, shard := range s.shards {
+		capacity += capacityOf(shard)
+	}
+
+	return capacity
+}
+
+func (s *shardedLRU[K, V]) backend() string {
+	return backendOf(s.shards[0])
+}
+
+func (s *shardedLRU[K, V]) deferRemovals() {
+	for _, shard := range s.shards {
+		deferRemovalsOf(shard)
+	}
+}
+
+func (s *shardedLRU[K, V]) flushRemovals() {
+	for _, shard := range s.shards {
+		flushRemovalsOf(shard)
+	}
+}
+
+// TopK merges the top keys of the shards. The shards don't share the notion of recency,
+// so the keys are ranked by their hits even with the LeastRecentlyUsed ordering
+func (s *shardedLRU[K, V]) TopK(n int) []KeyHits[K] {
+	var keys []KeyHits[K]
+	for _, shard := range s.shards {
+		keys = append(keys, shard.TopK(n)...)
+	}
+
+	slices.SortStableFunc(keys, func(a, b KeyHits[K]) int {
+		return cmp.Compare(b.Hits, a.Hits)
+	})
+	return keys[:min(max(n, 0), len(keys))]
+}
+
+// Coldest merges the coldest keys of the shards, ranking them by their hits like TopK
+func (s *shardedLRU[K, V]) Coldest(n int) []KeyHits[K] {
+	var keys []KeyHits[K]
+	for _, shard := range s.shards {
+		keys = append(keys, shard.Coldest(n)...)
+	}
+
+	slices.SortStableFunc(keys, func(a, b KeyHits[K]) int {
+		return cmp.Compare(a.Hits, b.Hits)
+	})
+	return keys[:min(max(n, 0), len(keys))]
+}
+
+// extractPopularityKeys returns the keys of the shards one after another,
+// the popularity of keys from different shards isn't comparable
+func (s *shardedLRU[K, V]) extractPopularityKeys() []K {
+	var keys []K
+	for _, shard := range s.shards {
+		keys = append(keys, shard.extractPopularityKeys()...)
+	}
+
+	return keys
+}
@@ -0,0 +1,146 @@
+package lru
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedLRU(t *testing.T) {
+	for name, factory := range map[string]Factory[string, string]{
+		"map":     NewMapLRU[string, string],
+		"list":    NewListLRU[string, string],
+		"bintree": NewBintreeLRU[string, string],
+	} {
+		t.Run(name, func(t *testing.T) {
+			cache := NewShardedLRU(4, 100, factory, WithTTL[string, string](time.Hour)).(*shardedLRU[string, string])
+
+			for i := 0; i < 200; i++ {
+				key := strconv.Itoa(i)
+				cache.Set(key, "value "+key)
+			}
+
+			assert.Equal(t, 200, cache.Size())
+			assert.Len(t, cache.extractPopularityKeys(), 200)
+			for _, shard := range cache.shards {
+				assert.NotZero(t, shard.Size(), "keys should be spread over all shards")
+			}
+
+			gotFound, gotValue := cache.Get("42")
+			assert.True(t, gotFound)
+			assert.Equal(t, "value 42", gotValue)
+
+			gotFound, gotPrevious := cache.Replace("42", "new value 42")
+			assert.True(t, gotFound)
+			assert.Equal(t, "value 42", gotPrevious)
+
+			gotFound, gotValue = cache.Peek("42")
+			assert.True(t, gotFound)
+			assert.Equal(t, "new value 42", gotValue)
+
+			assert.True(t, cache.Delete("42"))
+			assert.False(t, cache.Contains("42"))
+			assert.Equal(t, 199, cache.Size())
+
+			cache.SetWithTTL("42", "value 42", time.Minute)
+			assert.True(t, cache.Contains("42"))
+
+			shardFull := cache.shard("200").Size() == 100
+			_, _, gotEvicted := cache.SetAndEvict("200", "value 200")
+			assert.Equal(t, shardFull, gotEvicted)
+
+			cache.Clear()
+			assert.Equal(t, 0, cache.Size())
+			cache.Close()
+		})
+	}
+}
+
+func TestShardedLRU_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], func(capacity int, opts ...Option[int, int]) LRU[int, int] {
+		return NewShardedLRU(4, capacity/4, NewMapLRU[int, int], opts...)
+	})
+}
+
+// Run with -cpu 1,2,4,8 to see how the throughput scales with GOMAXPROCS
+func BenchmarkShardedLRU(b *testing.B) {
+	const keys = 1 << 16
+
+	// the least recently used ordering has constant cost of an access, so the benchmark measures the locking
+	ordering := WithOrdering[int, int](LeastRecentlyUsed)
+	caches := map[string]func() LRU[int, int]{
+		"synchronized": func() LRU[int, int] {
+			return NewSynchronized(NewMapLRU[int, int](keys/2, ordering))
+		},
+		"sharded 16": func() LRU[int, int] {
+			return NewShardedLRU(16, keys/2/16, NewMapLRU[int, int], ordering)
+		},
+		"sharded 64": func() LRU[int, int] {
+			return NewShardedLRU(64, keys/2/64, NewMapLRU[int, int], ordering)
+		},
+	}
+
+	for _, name := range []string{"synchronized", "sharded 16", "sharded 64"} {
+		b.Run(fmt.Sprintf("%s, 75%% reads", name), func(b *testing.B) {
+			cache := caches[name]()
+			b.RunParallel(func(pb *testing.PB) {
+				rnd := rand.New(rand.NewSource(rand.Int63()))
+				for pb.Next() {
+					key := rnd.Intn(keys)
+					if rnd.Intn(4) == 0 {
+						cache.Set(key, key)
+					} else {
+						cache.Get(key)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestShardedLRU_TopK(t *testing.T) {
+	cache := NewShardedLRU(4, 10, NewMapLRU[string, string])
+	for i := 0; i < 10; i++ {
+		key := strconv.Itoa(i)
+		cache.Set(key, "value "+key)
+		for j := 0; j < i; j++ {
+			cache.Get(key)
+		}
+	}
+
+	assert.Equal(t, []KeyHits[string]{{"9", 10}, {"8", 9}, {"7", 8}}, cache.TopK(3))
+	assert.Equal(t, []KeyHits[string]{{"0", 1}, {"1", 2}}, cache.Coldest(2))
+	assert.Len(t, cache.TopK(100), 10)
+}
+
+func TestShardedLRU_Policy(t *testing.T) {
+	assert.Panics(t, func() {
+		NewShardedLRU(4, 10, NewMapLRU[string, string], WithPolicy[string, string](&fifoPolicy[string]{}))
+	}, "a policy can't be shared between the shards")
+
+	var policies []*fifoPolicy[string]
+	cache := NewShardedLRU(4, 2, NewMapLRU[string, string], WithPolicyFactory[string, string](func(capacity int) Policy[string] {
+		assert.Equal(t, 2, capacity)
+		policy := &fifoPolicy[string]{}
+		policies = append(policies, policy)
+		return policy
+	})).(*shardedLRU[string, string])
+	assert.Len(t, policies, 4)
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		cache.Set(key, "value "+key)
+	}
+
+	for i, shard := range cache.shards {
+		assert.Equal(t, 2, shard.Size())
+		assert.Len(t, policies[i].keys, 2, "every shard keeps its own keys in its policy")
+		for _, key := range policies[i].keys {
+			assert.True(t, shard.Contains(key), key)
+		}
+	}
+}
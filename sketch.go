@@ -0,0 +1,136 @@
+package lru
+
+const (
+	sketchDepth = 4
+	// sketchCounterMask keeps the lower 3 bits of every 4-bit counter, it's used to halve the counters at once
+	sketchCounterMask = 0x7777777777777777
+)
+
+// countMinSketch estimates the frequencies of the keys with 4-bit counters. Every key has a counter in each of the rows
+// and its frequency is the smallest of them. The counters are halved after sampleSize increments, so the old
+// frequencies fade away. The optional doorkeeper keeps the keys seen once away from the counters
+type countMinSketch struct {
+	// rows keep 16 counters in every word
+	rows       [sketchDepth][]uint64
+	mask       uint64
+	additions  int
+	sampleSize int
+	doorkeeper *bloomFilter
+}
+
+// newCountMinSketch creates a sketch for the given number of keys. Every row has 4 counters per key,
+// so the sketch takes 8 bytes per key
+func newCountMinSketch(keys int, doorkeeper bool) *countMinSketch {
+	width := 16
+	for width < 4*keys {
+		width *= 2
+	}
+
+	s := &countMinSketch{mask: uint64(width - 1), sampleSize: 10 * max(keys, 1)}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, width/16)
+	}
+
+	if doorkeeper {
+		s.doorkeeper = newBloomFilter(width)
+	}
+
+	return s
+}
+
+// index returns the position of the counter of the hash in the row
+func (s *countMinSketch) index(hash uint64, row int) uint64 {
+	// double hashing: the upper half of the hash is the step between the rows
+	return (hash + uint64(row)*(hash>>32|1)) & s.mask
+}
+
+func (s *countMinSketch) counter(row int, index uint64) uint64 {
+	return s.rows[row][index/16] >> (index % 16 * 4) & 0xf
+}
+
+// increment counts an occurrence of the hash
+func (s *countMinSketch) increment(hash uint64) {
+	if s.doorkeeper != nil && !s.doorkeeper.put(hash) {
+		// the first occurrence only goes to the doorkeeper
+		s.tick()
+		return
+	}
+
+	for row := range s.rows {
+		index := s.index(hash, row)
+		if s.counter(row, index) < 0xf {
+			s.rows[row][index/16] += 1 << (index % 16 * 4)
+		}
+	}
+	s.tick()
+}
+
+// estimate returns the frequency of the hash
+func (s *countMinSketch) estimate(hash uint64) int {
+	frequency := uint64(0xf)
+	for row := range s.rows {
+		frequency = min(frequency, s.counter(row, s.index(hash, row)))
+	}
+
+	if s.doorkeeper != nil && s.doorkeeper.contains(hash) {
+		frequency++
+	}
+
+	return int(frequency)
+}
+
+func (s *countMinSketch) tick() {
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// reset halves all counters and clears the doorkeeper
+func (s *countMinSketch) reset() {
+	s.additions /= 2
+	for _, row := range s.rows {
+		for i := range row {
+			row[i] = row[i] >> 1 & sketchCounterMask
+		}
+	}
+
+	if s.doorkeeper != nil {
+		s.doorkeeper.clear()
+	}
+}
+
+// bloomFilter is a set of hashes which may report false positives. It uses two bits per hash
+type bloomFilter struct {
+	bits []uint64
+	mask uint64
+}
+
+// newBloomFilter creates a filter with the given number of bits, which has to be a power of 2 not less than 64
+func newBloomFilter(size int) *bloomFilter {
+	size = max(size, 64)
+	return &bloomFilter{bits: make([]uint64, size/64), mask: uint64(size - 1)}
+}
+
+func (f *bloomFilter) positions(hash uint64) (uint64, uint64) {
+	return hash & f.mask, hash >> 32 & f.mask
+}
+
+// put adds the hash to the filter and reports whether it was there already
+func (f *bloomFilter) put(hash uint64) bool {
+	first, second := f.positions(hash)
+	found := f.bits[first/64]&(1<<(first%64)) != 0 && f.bits[second/64]&(1<<(second%64)) != 0
+
+	f.bits[first/64] |= 1 << (first % 64)
+	f.bits[second/64] |= 1 << (second % 64)
+	return found
+}
+
+func (f *bloomFilter) contains(hash uint64) bool {
+	first, second := f.positions(hash)
+	return f.bits[first/64]&(1<<(first%64)) != 0 && f.bits[second/64]&(1<<(second%64)) != 0
+}
+
+func (f *bloomFilter) clear() {
+	clear(f.bits)
+}
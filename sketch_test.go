@@ -0,0 +1,75 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	sketch := newCountMinSketch(100, false)
+	assert.Equal(t, uint64(511), sketch.mask)
+	assert.Equal(t, 1000, sketch.sampleSize)
+
+	for i := 0; i < 5; i++ {
+		sketch.increment(42)
+	}
+	sketch.increment(7)
+
+	assert.Equal(t, 5, sketch.estimate(42))
+	assert.Equal(t, 1, sketch.estimate(7))
+	assert.Equal(t, 0, sketch.estimate(1000))
+
+	for i := 0; i < 20; i++ {
+		sketch.increment(42)
+	}
+	assert.Equal(t, 15, sketch.estimate(42), "counters saturate at 15")
+	assert.Equal(t, 1, sketch.estimate(7), "saturated counters don't overflow into their neighbours")
+}
+
+func TestCountMinSketch_Reset(t *testing.T) {
+	sketch := newCountMinSketch(1, false)
+	assert.Equal(t, 10, sketch.sampleSize)
+
+	for i := 0; i < 7; i++ {
+		sketch.increment(42)
+	}
+	sketch.increment(7)
+	sketch.increment(7)
+	assert.Equal(t, 7, sketch.estimate(42))
+
+	// the tenth increment halves the counters
+	sketch.increment(7)
+	assert.Equal(t, 3, sketch.estimate(42))
+	assert.Equal(t, 1, sketch.estimate(7))
+	assert.Equal(t, 5, sketch.additions)
+}
+
+func TestCountMinSketch_Doorkeeper(t *testing.T) {
+	sketch := newCountMinSketch(100, true)
+
+	sketch.increment(42)
+	assert.Equal(t, 1, sketch.estimate(42), "the first occurrence is counted by the doorkeeper")
+	assert.Zero(t, sketch.counter(0, sketch.index(42, 0)))
+
+	sketch.increment(42)
+	sketch.increment(42)
+	assert.Equal(t, 3, sketch.estimate(42))
+
+	sketch.reset()
+	assert.Equal(t, 1, sketch.estimate(42), "the reset clears the doorkeeper")
+	assert.False(t, sketch.doorkeeper.contains(42))
+}
+
+func TestBloomFilter(t *testing.T) {
+	filter := newBloomFilter(128)
+	assert.Len(t, filter.bits, 2)
+
+	assert.False(t, filter.put(1<<32|5))
+	assert.True(t, filter.put(1<<32|5))
+	assert.True(t, filter.contains(1<<32|5))
+	assert.False(t, filter.contains(1<<32|6))
+
+	filter.clear()
+	assert.False(t, filter.contains(1<<32|5))
+}
@@ -0,0 +1,97 @@
+package lru
+
+import "time"
+
+// Stats is a snapshot of the statistics of a cache
+type Stats struct {
+	// Hits is the number of Get calls which found the key
+	Hits uint64
+	// Misses is the number of Get calls which didn't find the key
+	Misses uint64
+	// Insertions is the number of new keys added to the cache
+	Insertions uint64
+	// Evictions is the number of items which left the cache by the reason, replaced values aren't counted here
+	Evictions map[RemovalReason]uint64
+	// Replacements is the number of values which were replaced by new values of the same keys
+	Replacements uint64
+	// LoadSuccesses is the number of values loaded by the LoadingCache
+	LoadSuccesses uint64
+	// LoadFailures is the number of loads of the LoadingCache which returned an error
+	LoadFailures uint64
+	// LoadTime is the total time spent in the loaders of the LoadingCache
+	LoadTime time.Duration
+}
+
+// Requests returns the number of Get calls
+func (s Stats) Requests() uint64 {
+	return s.Hits + s.Misses
+}
+
+// HitRatio returns the share of Get calls which found the key, it's 0 when there were no calls
+func (s Stats) HitRatio() float64 {
+	if s.Requests() == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Requests())
+}
+
+// AverageLoadTime returns the average time of a load, failed loads included
+func (s Stats) AverageLoadTime() time.Duration {
+	loads := s.LoadSuccesses + s.LoadFailures
+	if loads == 0 {
+		return 0
+	}
+
+	return s.LoadTime / time.Duration(loads)
+}
+
+// merge adds the counters of other to the stats
+func (s Stats) merge(other Stats) Stats {
+	evictions := make(map[RemovalReason]uint64, len(s.Evictions))
+	for reason, count := range s.Evictions {
+		evictions[reason] += count
+	}
+	for reason, count := range other.Evictions {
+		evictions[reason] += count
+	}
+
+	return Stats{
+		Hits:          s.Hits + other.Hits,
+		Misses:        s.Misses + other.Misses,
+		Insertions:    s.Insertions + other.Insertions,
+		Evictions:     evictions,
+		Replacements:  s.Replacements + other.Replacements,
+		LoadSuccesses: s.LoadSuccesses + other.LoadSuccesses,
+		LoadFailures:  s.LoadFailures + other.LoadFailures,
+		LoadTime:      s.LoadTime + other.LoadTime,
+	}
+}
+
+// evictionReasons lists the reasons counted in Stats.Evictions, it's used to report zero counters too.
+// ReasonReplaced isn't there, the replaced values are counted in Stats.Replacements
+var evictionReasons = []RemovalReason{ReasonCapacity, ReasonExpired, ReasonDeleted, ReasonCleared}
+
+// cacheStats keeps the counters of a cache, it's guarded by the lock of the cache
+type cacheStats struct {
+	hits         uint64
+	misses       uint64
+	insertions   uint64
+	evictions    [ReasonCleared + 1]uint64
+	replacements uint64
+}
+
+func (s *cacheStats) snapshot() Stats {
+	evictions := make(map[RemovalReason]uint64, len(evictionReasons))
+	for _, reason := range evictionReasons {
+		evictions[reason] = s.evictions[reason]
+	}
+
+	return Stats{
+		Hits:         s.hits,
+		Misses:       s.misses,
+		Insertions:   s.insertions,
+		Evictions:    evictions,
+		Replacements: s.replacements,
+	}
+}
@@ -0,0 +1,68 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	var empty Stats
+	assert.Zero(t, empty.HitRatio())
+	assert.Zero(t, empty.AverageLoadTime())
+
+	stats := Stats{
+		Hits:          3,
+		Misses:        1,
+		Evictions:     map[RemovalReason]uint64{ReasonCapacity: 2},
+		LoadSuccesses: 3,
+		LoadFailures:  1,
+		LoadTime:      time.Second,
+	}
+	assert.Equal(t, uint64(4), stats.Requests())
+	assert.Equal(t, 0.75, stats.HitRatio())
+	assert.Equal(t, 250*time.Millisecond, stats.AverageLoadTime())
+
+	gotStats := stats.merge(Stats{Hits: 1, Insertions: 2, Evictions: map[RemovalReason]uint64{ReasonCapacity: 1, ReasonDeleted: 1}, Replacements: 2})
+	assert.Equal(t, Stats{
+		Hits:          4,
+		Misses:        1,
+		Insertions:    2,
+		Evictions:     map[RemovalReason]uint64{ReasonCapacity: 3, ReasonDeleted: 1},
+		Replacements:  2,
+		LoadSuccesses: 3,
+		LoadFailures:  1,
+		LoadTime:      time.Second,
+	}, gotStats)
+	assert.Equal(t, uint64(2), stats.Evictions[ReasonCapacity], "merge doesn't change the original stats")
+}
+
+func TestStats_Wrappers(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache LRU[string, string]
+	}{
+		{"Synchronized", NewSynchronized(NewMapLRU[string, string](100))},
+		{"Concurrent", NewConcurrent(NewListLRU[string, string](100))},
+		{"Sharded", NewShardedLRU(4, 25, NewBintreeLRU[string, string])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				tt.cache.Set(strconv.Itoa(i), "value")
+			}
+			for i := 0; i < 1000; i++ {
+				tt.cache.Get(strconv.Itoa(i % 20))
+			}
+
+			gotStats := tt.cache.Stats()
+			assert.Equal(t, uint64(500), gotStats.Hits, "replayed reads shouldn't be counted twice")
+			assert.Equal(t, uint64(500), gotStats.Misses)
+			assert.Equal(t, uint64(10), gotStats.Insertions)
+			assert.Equal(t, 0.5, gotStats.HitRatio())
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// synchronizedLRU guards every operation of the wrapped cache with a mutex
+type synchronizedLRU[K comparable, V any] struct {
+	mu  sync.Mutex
+	lru LRU[K, V]
+	// deferred is set when the notifications about removed items are flushed by another wrapper
+	deferred bool
+}
+
+// NewSynchronized wraps the cache, so it can be shared between goroutines. The wrapped cache shouldn't be used directly
+func NewSynchronized[K comparable, V any](lru LRU[K, V]) LRU[K, V] {
+	deferRemovalsOf(lru)
+	return &synchronizedLRU[K, V]{lru: lru}
+}
+
+func (s *synchronizedLRU[K, V]) Get(key K) (bool, V) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Get(key)
+}
+
+func (s *synchronizedLRU[K, V]) Set(key K, value V) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.Set(key, value)
+}
+
+func (s *synchronizedLRU[K, V]) SetAndEvict(key K, value V) (K, V, bool) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.SetAndEvict(key, value)
+}
+
+func (s *synchronizedLRU[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.SetWithTTL(key, value, ttl)
+}
+
+func (s *synchronizedLRU[K, V]) Replace(key K, value V) (bool, V) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Replace(key, value)
+}
+
+func (s *synchronizedLRU[K, V]) Size() int {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Size()
+}
+
+func (s *synchronizedLRU[K, V]) Delete(key K) bool {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Delete(key)
+}
+
+func (s *synchronizedLRU[K, V]) Peek(key K) (bool, V) {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Peek(key)
+}
+
+func (s *synchronizedLRU[K, V]) Contains(key K) bool {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Contains(key)
+}
+
+func (s *synchronizedLRU[K, V]) Clear() {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.Clear()
+}
+
+func (s *synchronizedLRU[K, V]) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Stats()
+}
+
+func (s *synchronizedLRU[K, V]) Close() {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.Close()
+}
+
+func (s *synchronizedLRU[K, V]) TopK(n int) []KeyHits[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.TopK(n)
+}
+
+func (s *synchronizedLRU[K, V]) Coldest(n int) []KeyHits[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Coldest(n)
+}
+
+func (s *synchronizedLRU[K, V]) extractPopularityKeys() []K {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.extractPopularityKeys()
+}
+
+func (s *synchronizedLRU[K, V]) limit() int {
+	return capacityOf(s.lru)
+}
+
+func (s *synchronizedLRU[K, V]) backend() string {
+	return backendOf(s.lru)
+}
+
+func (s *synchronizedLRU[K, V]) deferRemovals() {
+	s.deferred = true
+}
+
+func (s *synchronizedLRU[K, V]) flushRemovals() {
+	flushRemovalsOf(s.lru)
+}
+
+// notify sends the notifications about removed items after the lock is released
+func (s *synchronizedLRU[K, V]) notify() {
+	if !s.deferred {
+		flushRemovalsOf(s.lru)
+	}
+}
@@ -0,0 +1,93 @@
+package lru
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConcurrentLRUCache hammers the cache wrapped for concurrent access with a mix of operations from several goroutines
+func testConcurrentLRUCache(t *testing.T, wrap func(LRU[int, int]) LRU[int, int], newLRU func(capacity int, opts ...Option[int, int]) LRU[int, int]) {
+	const (
+		capacity   = 64
+		goroutines = 8
+		operations = 5000
+		keys       = 256
+	)
+
+	for _, ordering := range []Ordering{LeastFrequentlyUsed, LeastRecentlyUsed} {
+		cache := wrap(newLRU(capacity,
+			WithOrdering[int, int](ordering),
+			WithTTL[int, int](time.Minute),
+			WithExpireAfterAccess[int, int](time.Minute),
+		))
+
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+
+				rnd := rand.New(rand.NewSource(seed))
+				for i := 0; i < operations; i++ {
+					key := rnd.Intn(keys)
+					switch op := rnd.Intn(10); {
+					case op < 5:
+						if found, value := cache.Get(key); found {
+							assert.Equal(t, key, value)
+						}
+					case op < 8:
+						cache.Set(key, key)
+					case op < 9:
+						cache.Delete(key)
+					default:
+						cache.SetWithTTL(key, key, time.Millisecond)
+					}
+
+					assert.LessOrEqual(t, cache.Size(), capacity)
+				}
+			}(int64(g))
+		}
+		wg.Wait()
+
+		assert.LessOrEqual(t, cache.Size(), capacity)
+		assert.LessOrEqual(t, len(cache.extractPopularityKeys()), cache.Size())
+
+		cache.Clear()
+		assert.Equal(t, 0, cache.Size())
+		cache.Close()
+	}
+}
+
+func TestSynchronized(t *testing.T) {
+	cache := NewSynchronized(NewMapLRU[string, string](2))
+	cache.Set("a", "value a")
+	cache.SetWithTTL("b", "value b", time.Minute)
+
+	gotFound, gotPrevious := cache.Replace("a", "new value a")
+	assert.True(t, gotFound)
+	assert.Equal(t, "value a", gotPrevious)
+
+	gotFound, gotValue := cache.Get("a")
+	assert.True(t, gotFound)
+	assert.Equal(t, "new value a", gotValue)
+
+	gotFound, gotValue = cache.Peek("b")
+	assert.True(t, gotFound)
+	assert.Equal(t, "value b", gotValue)
+
+	assert.True(t, cache.Delete("b"))
+	assert.False(t, cache.Contains("b"))
+	assert.Equal(t, []string{"a"}, cache.extractPopularityKeys())
+	assert.Equal(t, 1, cache.Size())
+
+	_, _, gotEvicted := cache.SetAndEvict("c", "value c")
+	assert.False(t, gotEvicted)
+	gotKey, gotValue, gotEvicted := cache.SetAndEvict("d", "value d")
+	assert.True(t, gotEvicted)
+	assert.Equal(t, "c", gotKey)
+	assert.Equal(t, "value c", gotValue)
+}
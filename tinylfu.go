@@ -0,0 +1,162 @@
+package lru
+
+import "hash/maphash"
+
+/*
+	W-TinyLFU (Einziger, Friedman and Manes, 2017).
+
+	New keys go to a small window LRU, which lets bursts of new keys stay in the cache for a while. When the window
+	is full its oldest key is a candidate for the main region, which is a segmented LRU: keys enter the probationary
+	segment and move to the protected segment on their next access. When the cache is full the candidate competes
+	with the victim of the main region and the key with the lower frequency is evicted. The frequencies come from
+	a count-min sketch, so they include the keys which were evicted or haven't been in the cache at all.
+
+	All lists keep the most recently used key at the head
+*/
+
+const (
+	// tinyLFUWindowPercent is the share of the capacity for the window
+	tinyLFUWindowPercent = 1
+	// tinyLFUProtectedPercent is the share of the main region for the protected segment
+	tinyLFUProtectedPercent = 80
+)
+
+type tinyLFUPolicy[K comparable] struct {
+	windowSize    int
+	protectedSize int
+	// hash feeds the keys to the sketch, the tests replace it to get the same hashes in every run
+	hash      func(key K) uint64
+	sketch    *countMinSketch
+	entries   map[K]*queueEntry[K]
+	window    popularityList[K]
+	probation popularityList[K]
+	protected popularityList[K]
+}
+
+// NewTinyLFUPolicy creates a W-TinyLFU policy for the cache with the given capacity. The doorkeeper is a Bloom filter
+// in front of the frequency sketch, it keeps the keys seen once out of the sketch and makes it more accurate
+func NewTinyLFUPolicy[K comparable](capacity int, doorkeeper bool) Policy[K] {
+	capacity = max(capacity, 1)
+	windowSize := max(capacity*tinyLFUWindowPercent/100, 1)
+
+	seed := maphash.MakeSeed()
+	return &tinyLFUPolicy[K]{
+		windowSize:    windowSize,
+		protectedSize: (capacity - windowSize) * tinyLFUProtectedPercent / 100,
+		hash: func(key K) uint64 {
+			return maphash.Comparable(seed, key)
+		},
+		sketch:  newCountMinSketch(capacity, doorkeeper),
+		entries: make(map[K]*queueEntry[K]),
+	}
+}
+
+// NewTinyLFUCache creates a W-TinyLFU cache with a map as a backend. The doorkeeper is enabled with WithDoorkeeper,
+// WithOrdering and WithAging don't apply to it
+func NewTinyLFUCache[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return NewMapLRU(capacity, append(opts, WithOrdering[K, V](TinyLFU))...)
+}
+
+func (p *tinyLFUPolicy[K]) OnInsert(key K) {
+	if _, ok := p.entries[key]; ok {
+		p.OnAccess(key)
+		return
+	}
+
+	p.sketch.increment(p.hash(key))
+
+	entry := &queueEntry[K]{node: popularityNode[K]{key: key, hits: 1}}
+	p.entries[key] = entry
+	p.push(entry, &p.window)
+
+	// the cache isn't full yet, so the oldest keys of the window go to the main region without a competition
+	for p.window.size > p.windowSize {
+		p.move(p.entries[p.window.popularityTail.key], &p.probation)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	p.sketch.increment(p.hash(key))
+	entry.node.hits++
+
+	switch entry.list {
+	case &p.window, &p.protected:
+		entry.list.moveToFront(&entry.node)
+
+	case &p.probation:
+		p.move(entry, &p.protected)
+		for p.protected.size > p.protectedSize {
+			p.move(p.entries[p.protected.popularityTail.key], &p.probation)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy[K]) OnRemove(key K) {
+	if entry, ok := p.entries[key]; ok {
+		entry.list.unlink(&entry.node)
+		delete(p.entries, key)
+	}
+}
+
+// Victim picks the loser of the competition between the oldest key of the window and the victim of the main region.
+// The winner from the window moves to the main region when the new key pushes it out of the window
+func (p *tinyLFUPolicy[K]) Victim() (key K, found bool) {
+	var victim *popularityNode[K]
+	switch {
+	case p.probation.popularityTail != nil:
+		victim = p.probation.popularityTail
+	case p.protected.popularityTail != nil:
+		victim = p.protected.popularityTail
+	}
+
+	candidate := p.window.popularityTail
+	switch {
+	case candidate == nil && victim == nil:
+		return key, false
+	case candidate == nil || (victim != nil && p.window.size < p.windowSize):
+		return victim.key, true
+	case victim == nil:
+		return candidate.key, true
+	}
+
+	if p.sketch.estimate(p.hash(candidate.key)) > p.sketch.estimate(p.hash(victim.key)) {
+		return victim.key, true
+	}
+
+	return candidate.key, true
+}
+
+func (p *tinyLFUPolicy[K]) push(entry *queueEntry[K], list *popularityList[K]) {
+	entry.list = list
+	list.pushFront(&entry.node)
+}
+
+func (p *tinyLFUPolicy[K]) move(entry *queueEntry[K], list *popularityList[K]) {
+	entry.list.unlink(&entry.node)
+	p.push(entry, list)
+}
+
+// topK returns the keys of the protected segment followed by the keys of the probationary segment and the window
+func (p *tinyLFUPolicy[K]) topK(n int) []KeyHits[K] {
+	keys := p.protected.topK(n)
+	keys = append(keys, p.probation.topK(n-len(keys))...)
+	return append(keys, p.window.topK(n-len(keys))...)
+}
+
+// coldest returns the keys in the reverse order of topK
+func (p *tinyLFUPolicy[K]) coldest(n int) []KeyHits[K] {
+	keys := p.window.coldest(n)
+	keys = append(keys, p.probation.coldest(n-len(keys))...)
+	return append(keys, p.protected.coldest(n-len(keys))...)
+}
+
+func (p *tinyLFUPolicy[K]) extractPopularityKeys() []K {
+	keys := p.protected.extractPopularityKeys()
+	keys = append(keys, p.probation.extractPopularityKeys()...)
+	return append(keys, p.window.extractPopularityKeys()...)
+}
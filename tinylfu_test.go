@@ -0,0 +1,113 @@
+package lru
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTinyLFUCache creates a TinyLFU cache with string keys and the hash of withTestHash
+func newTestTinyLFUCache(capacity int, opts ...Option[string, string]) *cache[string, string] {
+	return withTestHash(NewTinyLFUCache(capacity, opts...)).(*cache[string, string])
+}
+
+// withTestHash replaces the random hash of the TinyLFU cache with FNV-64a, so the sketch is the same in every run
+func withTestHash[K comparable, V any](lru LRU[K, V]) LRU[K, V] {
+	lru.(*cache[K, V]).policy.(*tinyLFUPolicy[K]).hash = func(key K) uint64 {
+		hash := fnv.New64a()
+		fmt.Fprint(hash, key)
+		return hash.Sum64()
+	}
+
+	return lru
+}
+
+func TestTinyLFUCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewTinyLFUCache[int, int])
+}
+
+func TestTinyLFUCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewTinyLFUCache[int, int])
+}
+
+func TestTinyLFUCache_Segments(t *testing.T) {
+	cache := newTestTinyLFUCache(10)
+	policy := cache.policy.(*tinyLFUPolicy[string])
+	assert.Equal(t, 1, policy.windowSize)
+	assert.Equal(t, 7, policy.protectedSize)
+
+	for i := 0; i < 3; i++ {
+		cache.Set(strconv.Itoa(i), "value")
+	}
+	assert.Equal(t, []string{"2"}, policy.window.extractPopularityKeys())
+	assert.Equal(t, []string{"1", "0"}, policy.probation.extractPopularityKeys())
+
+	cache.Get("0")
+	cache.Get("2")
+	assert.Equal(t, []string{"0"}, policy.protected.extractPopularityKeys(), "an access moves the key to the protected segment")
+	assert.Equal(t, []string{"0", "1", "2"}, cache.extractPopularityKeys())
+	assert.Equal(t, []string{"2"}, policy.window.extractPopularityKeys(), "an access in the window doesn't promote the key")
+
+	for i := 3; i < 10; i++ {
+		cache.Set(strconv.Itoa(i), "value")
+	}
+	for i := 1; i < 9; i++ {
+		cache.Get(strconv.Itoa(i))
+	}
+	assert.Equal(t, 7, policy.protected.size)
+	assert.Equal(t, []string{"1", "0"}, policy.probation.extractPopularityKeys(), "the protected segment overflows to the probationary one")
+	assert.Equal(t, []string{"9"}, policy.window.extractPopularityKeys())
+
+	assert.Equal(t, []KeyHits[string]{{"8", 2}, {"7", 2}}, cache.TopK(2))
+	assert.Equal(t, []KeyHits[string]{{"9", 1}, {"0", 2}}, cache.Coldest(2))
+}
+
+func TestTinyLFUCache_Admission(t *testing.T) {
+	for _, doorkeeper := range []bool{false, true} {
+		var opts []Option[string, string]
+		if doorkeeper {
+			opts = append(opts, WithDoorkeeper[string, string]())
+		}
+		cache := newTestTinyLFUCache(10, opts...)
+
+		for i := 0; i < 10; i++ {
+			key := strconv.Itoa(i)
+			cache.Set(key, "value")
+			for j := 0; j < 3; j++ {
+				cache.Get(key)
+			}
+		}
+
+		// keys used once don't get into the main region, while the sketch remembers the frequent keys
+		for i := 0; i < 30; i++ {
+			cache.Set("once "+strconv.Itoa(i), "value")
+		}
+
+		for i := 0; i < 9; i++ {
+			assert.True(t, cache.Contains(strconv.Itoa(i)), "doorkeeper %v: key %d should stay", doorkeeper, i)
+		}
+		assert.True(t, cache.Contains("once 29"), "the newest key stays in the window")
+
+		// a key which keeps coming back is admitted
+		for i := 0; i < 10; i++ {
+			cache.Set("returning", "value")
+			cache.Set("new "+strconv.Itoa(i), "value")
+		}
+		assert.True(t, cache.Contains("returning"), "doorkeeper %v", doorkeeper)
+		assert.Equal(t, 10, cache.Size())
+	}
+}
+
+func TestTinyLFUCache_HitRatio(t *testing.T) {
+	for _, trace := range []hitRatioTrace{zipfHitRatioTrace, zipfWithScansHitRatioTrace} {
+		lru := replay(NewMapLRU(200, WithOrdering[int, int](LeastRecentlyUsed)), trace.keys())
+		tinyLFU := replay(withTestHash(NewTinyLFUCache[int, int](200)), trace.keys())
+		doorkeeper := replay(withTestHash(NewTinyLFUCache(200, WithDoorkeeper[int, int]())), trace.keys())
+
+		assert.Greater(t, tinyLFU, lru+0.05, trace.name)
+		assert.Greater(t, doorkeeper, lru+0.05, trace.name)
+	}
+}
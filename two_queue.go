@@ -0,0 +1,155 @@
+package lru
+
+/*
+	2Q cache (Johnson and Shasha, 1994), the full version.
+
+	New keys go to A1in, a FIFO queue of the keys seen once. The keys evicted from A1in are remembered in A1out,
+	a FIFO queue of ghost keys. A key which comes back while it's in A1out is promoted to Am, the LRU list of hot keys.
+	Accesses to the keys in A1in don't change anything, so a scan passes through A1in without touching Am.
+	The victim is taken from A1in when it's over its size and from Am otherwise.
+
+	All lists keep the newest key at the head
+*/
+
+const (
+	defaultTwoQueueIn  = 0.25
+	defaultTwoQueueOut = 0.5
+)
+
+type twoQueuePolicy[K comparable] struct {
+	// inSize and outSize are the sizes of A1in and A1out, called Kin and Kout in the paper
+	inSize  int
+	outSize int
+	entries map[K]*queueEntry[K]
+	in, out popularityList[K]
+	main    popularityList[K]
+	// victim is the last key returned by Victim, it goes to A1out when it's removed from A1in
+	victim    K
+	hasVictim bool
+}
+
+// NewTwoQueuePolicy creates a 2Q policy for the cache with the given capacity. in is the share of the capacity
+// for the probationary queue and out is the number of remembered evicted keys as a share of the capacity.
+// Shares which aren't positive are replaced with the defaults 0.25 and 0.5
+func NewTwoQueuePolicy[K comparable](capacity int, in, out float64) Policy[K] {
+	if in <= 0 {
+		in = defaultTwoQueueIn
+	}
+	if out <= 0 {
+		out = defaultTwoQueueOut
+	}
+
+	return &twoQueuePolicy[K]{
+		inSize:  max(int(in*float64(capacity)), 1),
+		outSize: max(int(out*float64(capacity)), 1),
+		entries: make(map[K]*queueEntry[K]),
+	}
+}
+
+// NewTwoQueueCache creates a 2Q cache with a map as a backend. The sizes of the queues are set by WithTwoQueueRatios,
+// WithOrdering and WithAging don't apply to it
+func NewTwoQueueCache[K comparable, V any](capacity int, opts ...Option[K, V]) LRU[K, V] {
+	return NewMapLRU(capacity, append(opts, WithOrdering[K, V](TwoQueue))...)
+}
+
+// onMiss takes the new key out of A1out before the cache evicts something, so the eviction can't push it out
+func (p *twoQueuePolicy[K]) onMiss(key K) {
+	if entry, ok := p.entries[key]; ok && entry.list == &p.out {
+		p.out.unlink(&entry.node)
+		entry.list = nil
+	}
+}
+
+func (p *twoQueuePolicy[K]) OnInsert(key K) {
+	entry, ok := p.entries[key]
+	switch {
+	case !ok:
+		entry = &queueEntry[K]{node: popularityNode[K]{key: key}}
+		p.entries[key] = entry
+		p.push(entry, &p.in)
+
+	case entry.list == nil || entry.list == &p.out:
+		// the key was evicted from A1in and came back, so it's hot
+		if entry.list == &p.out {
+			p.out.unlink(&entry.node)
+		}
+		p.push(entry, &p.main)
+
+	default:
+		p.OnAccess(key)
+		return
+	}
+
+	entry.node.hits = 1
+}
+
+func (p *twoQueuePolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.list == nil || entry.list == &p.out {
+		return
+	}
+
+	entry.node.hits++
+	if entry.list == &p.main {
+		p.main.moveToFront(&entry.node)
+	}
+}
+
+// OnRemove moves the victim from A1in to A1out, the keys removed for other reasons are forgotten
+func (p *twoQueuePolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.list == nil || entry.list == &p.out {
+		return
+	}
+
+	victim := p.hasVictim && p.victim == key
+	p.hasVictim = false
+
+	entry.list.unlink(&entry.node)
+	if !victim || entry.list == &p.main {
+		delete(p.entries, key)
+		return
+	}
+
+	p.push(entry, &p.out)
+	for p.out.size > p.outSize {
+		node := p.out.popularityTail
+		p.out.unlink(node)
+		delete(p.entries, node.key)
+	}
+}
+
+func (p *twoQueuePolicy[K]) Victim() (key K, found bool) {
+	list := &p.main
+	if p.in.size > p.inSize || p.main.size == 0 {
+		list = &p.in
+	}
+
+	if list.popularityTail == nil {
+		return key, false
+	}
+
+	p.victim, p.hasVictim = list.popularityTail.key, true
+	return p.victim, true
+}
+
+func (p *twoQueuePolicy[K]) push(entry *queueEntry[K], list *popularityList[K]) {
+	entry.list = list
+	list.pushFront(&entry.node)
+}
+
+// topK returns the keys of Am followed by the keys of A1in
+func (p *twoQueuePolicy[K]) topK(n int) []KeyHits[K] {
+	keys := p.main.topK(n)
+	return append(keys, p.in.topK(n-len(keys))...)
+}
+
+// coldest returns the keys of A1in followed by the keys of Am, starting from their tails
+func (p *twoQueuePolicy[K]) coldest(n int) []KeyHits[K] {
+	keys := p.in.coldest(n)
+	return append(keys, p.main.coldest(n-len(keys))...)
+}
+
+func (p *twoQueuePolicy[K]) extractPopularityKeys() []K {
+	return append(p.main.extractPopularityKeys(), p.in.extractPopularityKeys()...)
+}
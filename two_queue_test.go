@@ -0,0 +1,89 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoQueueCache_Synchronized(t *testing.T) {
+	testConcurrentLRUCache(t, NewSynchronized[int, int], NewTwoQueueCache[int, int])
+}
+
+func TestTwoQueueCache_Concurrent(t *testing.T) {
+	testConcurrentLRUCache(t, NewConcurrent[int, int], NewTwoQueueCache[int, int])
+}
+
+func TestTwoQueueCache_Queues(t *testing.T) {
+	cache := NewTwoQueueCache[string, string](4, WithTwoQueueRatios[string, string](0.5, 0.5)).(*cache[string, string])
+	policy := cache.policy.(*twoQueuePolicy[string])
+	assert.Equal(t, 2, policy.inSize)
+	assert.Equal(t, 2, policy.outSize)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		cache.Set(key, "value "+key)
+	}
+	cache.Get("a")
+	assert.Equal(t, []string{"d", "c", "b", "a"}, policy.in.extractPopularityKeys(), "reads don't reorder A1in")
+
+	// A1in is over its size, so its oldest keys are evicted and remembered in A1out
+	cache.Set("e", "value e")
+	cache.Set("f", "value f")
+	assert.Equal(t, []string{"b", "a"}, policy.out.extractPopularityKeys())
+	assert.False(t, cache.Contains("a"))
+
+	// a remembered key is promoted to Am when it comes back
+	cache.Set("a", "value a")
+	assert.Equal(t, []string{"a"}, policy.main.extractPopularityKeys())
+	assert.Equal(t, []string{"c", "b"}, policy.out.extractPopularityKeys())
+	assert.Equal(t, []string{"a", "f", "e", "d"}, cache.extractPopularityKeys())
+
+	// A1out forgets the oldest keys
+	cache.Set("g", "value g")
+	assert.Equal(t, []string{"d", "c"}, policy.out.extractPopularityKeys())
+	assert.Len(t, policy.entries, 6)
+
+	// Am is an LRU list and loses its keys only when A1in is within its size
+	cache.Set("d", "value d")
+	cache.Set("c", "value c")
+	assert.Equal(t, []string{"c", "d"}, policy.main.extractPopularityKeys())
+	assert.Equal(t, []string{"g", "f"}, policy.in.extractPopularityKeys())
+	assert.False(t, cache.Contains("a"))
+	assert.Equal(t, []string{"e"}, policy.out.extractPopularityKeys(), "keys evicted from Am are forgotten")
+
+	cache.Get("d")
+	cache.Set("h", "value h")
+	assert.Equal(t, []string{"d"}, policy.main.extractPopularityKeys())
+	assert.Equal(t, []string{"h", "g", "f"}, policy.in.extractPopularityKeys())
+}
+
+func TestTwoQueueCache_DefaultRatios(t *testing.T) {
+	cache := NewTwoQueueCache[string, string](100, WithTwoQueueRatios[string, string](0, -1)).(*cache[string, string])
+	policy := cache.policy.(*twoQueuePolicy[string])
+	assert.Equal(t, 25, policy.inSize)
+	assert.Equal(t, 50, policy.outSize)
+}
+
+// TestTwoQueueCache_ScanResistance checks that a scan of keys used once doesn't flush the keys used often
+func TestTwoQueueCache_ScanResistance(t *testing.T) {
+	cache := NewTwoQueueCache[string, string](8)
+	for round := 0; round < 2; round++ {
+		for _, key := range []string{"hot 1", "hot 2", "hot 3"} {
+			cache.Set(key, "value")
+		}
+
+		// pushes the hot keys out of A1in, so they are promoted on the next round
+		for i := 0; i < 8; i++ {
+			cache.Set("warm up "+strconv.Itoa(round)+" "+strconv.Itoa(i), "value")
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		cache.Set(strconv.Itoa(i), "value")
+	}
+
+	for _, key := range []string{"hot 1", "hot 2", "hot 3"} {
+		assert.True(t, cache.Contains(key), key)
+	}
+}